@@ -0,0 +1,180 @@
+package pencil
+
+import (
+	"strconv"
+	"strings"
+
+	runewidth "github.com/mattn/go-runewidth"
+)
+
+// escapeLen returns the length of the escape sequence at the start of b,
+// which starts with ESC; ok is false if the sequence is incomplete, i.e. b
+// ends before its final byte. The following sequences are recognized:
+//
+//	CSI: ESC [ parameters intermediates final   e.g. ESC [ 1 ; 31 m
+//	OSC: ESC ] ... (BEL | ESC \)                e.g. ESC ] 8 ; ; url ESC \
+//	DCS, SOS, PM, APC: ESC (P | X | ^ | _) ... ESC \
+//	others: ESC intermediates final             e.g. ESC ( B
+func escapeLen(b []byte) (n int, ok bool) {
+	if len(b) < 2 {
+		return 0, false
+	}
+	switch b[1] {
+	case '[':
+		for i := 2; i < len(b); i++ {
+			c := b[i]
+			switch {
+			case c >= 0x40 && c <= 0x7e: // final byte
+				return i + 1, true
+			case c < 0x20 || c > 0x7e: // not part of a CSI: end it here
+				return i, true
+			}
+		}
+		return 0, false
+	case ']', 'P', 'X', '^', '_':
+		for i := 2; i < len(b); i++ {
+			switch b[i] {
+			case '\a':
+				if b[1] == ']' {
+					return i + 1, true
+				}
+			case 0x1b:
+				if i+1 >= len(b) {
+					return 0, false
+				}
+				if b[i+1] == '\\' {
+					return i + 2, true
+				}
+				return i, true // aborted by another sequence
+			}
+		}
+		return 0, false
+	default:
+		i := 1
+		for i < len(b) && b[i] >= 0x20 && b[i] <= 0x2f {
+			i++
+		}
+		if i >= len(b) {
+			return 0, false
+		}
+		return i + 1, true
+	}
+}
+
+// Strip returns s without escape sequences; an incomplete sequence at the
+// end of s is removed as well
+func Strip(s string) string {
+	if strings.IndexByte(s, 0x1b) < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		j := strings.IndexByte(s[i:], 0x1b)
+		if j < 0 {
+			b.WriteString(s[i:])
+			break
+		}
+		b.WriteString(s[i : i+j])
+		i += j
+		n, ok := escapeLen([]byte(s[i:]))
+		if !ok {
+			break
+		}
+		i += n
+	}
+	return b.String()
+}
+
+// VisibleWidth returns the number of columns s occupies on a terminal: the
+// escape sequences take none and wide characters, e.g. CJK, take two
+func VisibleWidth(s string) int {
+	return runewidth.StringWidth(Strip(s))
+}
+
+// isSGR reports whether the escape sequence seq is an SGR sequence: ESC [ ... m
+func isSGR(seq []byte) bool {
+	if len(seq) < 3 || seq[1] != '[' || seq[len(seq)-1] != 'm' {
+		return false
+	}
+	for _, c := range seq[2 : len(seq)-1] {
+		if (c < '0' || c > '9') && c != ';' && c != ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// downgradeSGR converts the colors of the SGR parameters params, e.g.
+// "1;38;2;255;136;0", to mode; basic colors and attributes are kept and
+// underline colors (58) are dropped for ModeANSI8.
+func downgradeSGR(params string, mode ColorMode) string {
+	if mode >= ModeRGB || params == "" {
+		return params
+	}
+	fields := strings.Split(params, ";")
+	out := make([]string, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		var kind string
+		var args []string
+		if strings.Contains(f, ":") {
+			// ISO 8613-6 form: 38:5:n or 38:2:[colorspace]:r:g:b
+			sub := strings.Split(f, ":")
+			kind, args = sub[0], sub[1:]
+			if len(args) == 5 && args[0] == "2" {
+				args = append(args[:1], args[2:]...)
+			}
+		} else {
+			kind = f
+			switch {
+			case i+2 < len(fields) && fields[i+1] == "5":
+				args = fields[i+1 : i+3]
+			case i+4 < len(fields) && fields[i+1] == "2":
+				args = fields[i+1 : i+5]
+			}
+		}
+		if kind != "38" && kind != "48" && kind != "58" || len(args) == 0 {
+			out = append(out, f)
+			continue
+		}
+		if !strings.Contains(f, ":") {
+			i += len(args)
+		}
+
+		spec := extendedColor(args)
+		switch {
+		case spec == nil:
+			continue // malformed: drop it
+		case kind == "58":
+			if mode == ModeANSI8 {
+				continue
+			}
+			out = append(out, "58;5;"+strconv.Itoa(int(spec.In(mode).Code)))
+		default:
+			out = append(out, spec.In(mode).params(kind == "48"))
+		}
+	}
+	return strings.Join(out, ";")
+}
+
+// extendedColor returns the color of the arguments of an extended color
+// parameter: ["5", n] or ["2", r, g, b]
+func extendedColor(args []string) *ColorSpec {
+	var v [3]int
+	for i := 1; i < len(args) && i <= 3; i++ {
+		n, err := strconv.Atoi(args[i])
+		if err != nil || n < 0 || n > 255 {
+			return nil
+		}
+		v[i-1] = n
+	}
+	switch {
+	case args[0] == "5" && len(args) == 2:
+		return IndexColor(ColorCode(v[0]))
+	case args[0] == "2" && len(args) == 4:
+		return RGBColor(uint8(v[0]), uint8(v[1]), uint8(v[2]))
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+package ansi256
+
+import "github.com/shyang107/pencil"
+
+// Adaptive is a pair of colors for terminals with a light and with a dark
+// background; the one matching pencil.BackgroundIsDark() is used.
+type Adaptive struct {
+	Light pencil.ColorCode
+	Dark  pencil.ColorCode
+}
+
+// Code returns the color code matching the background of the terminal
+func (a Adaptive) Code() pencil.ColorCode {
+	if pencil.BackgroundIsDark() {
+		return a.Dark
+	}
+	return a.Light
+}
+
+// Color returns the color object matching the background of the terminal
+func (a Adaptive) Color() *Color {
+	return getCachedColor(a.Code())
+}
+
+// Sprint is just like Color.Sprint with the color matching the background
+func (a Adaptive) Sprint(v ...interface{}) string {
+	return a.Color().Sprint(v...)
+}
+
+// Sprintf is just like Color.Sprintf with the color matching the background
+func (a Adaptive) Sprintf(format string, v ...interface{}) string {
+	return a.Color().Sprintf(format, v...)
+}
+
+// Sprintln is just like Color.Sprintln with the color matching the background
+func (a Adaptive) Sprintln(v ...interface{}) string {
+	return a.Color().Sprintln(v...)
+}
+
+// Adaptive grays: the dark variants are the four levels of gray FgGray1-4
+// and the shades of gray 59 and 60, the light variants keep the same
+// contrast against a light background
+var (
+	Gray1      = Adaptive{Light: 249, Dark: FgGray1}
+	Gray2      = Adaptive{Light: 246, Dark: FgGray2}
+	Gray3      = Adaptive{Light: 239, Dark: FgGray3}
+	Gray4      = Adaptive{Light: 234, Dark: FgGray4}
+	ShadeGray1 = Adaptive{Light: 145, Dark: 59}
+	ShadeGray2 = Adaptive{Light: 103, Dark: 60}
+)
+
+// adaptiveString returns a formatted colorful string with the color of a
+// matching the background of the terminal
+func adaptiveString(format string, a Adaptive, v ...interface{}) string {
+	return colorString(format, a.Code(), v...)
+}
@@ -5,8 +5,10 @@ import (
 	"io"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/ansirgb"
 )
 
 // const (
@@ -17,6 +19,9 @@ import (
 var (
 	colorsCache   = make(map[pencil.ColorCode]*Color)
 	colorsCacheMu sync.Mutex // protects colorsCache
+
+	// trailing resets the colors after a colored text
+	trailing = pencil.GetDefaultGround() + pencil.GetRest()
 )
 
 // Color defines a custom color object which is defined by 256-color mode parameters.
@@ -25,7 +30,15 @@ var (
 type Color struct {
 	Code    pencil.ColorCode // color index
 	params  []pencil.Attribute
-	noColor *bool // use DisableColor() or EnableColor() to setup
+	noColor *bool        // use DisableColor() or EnableColor() to setup
+	leading atomic.Value // *leading, the cached SGR sequences (see formatIn)
+}
+
+// leading holds the SGR sequences of a Color with the color index code in
+// each color mode
+type leading struct {
+	code pencil.ColorCode
+	seq  [pencil.ModeRGB + 1]string
 }
 
 //---------------------------------------------------------
@@ -94,6 +107,7 @@ func New(code pencil.ColorCode, params ...pencil.Attribute) *Color {
 // and create custom color objects. Example: Add(color.FgRed, color.Underline).
 func (c *Color) Add(value ...pencil.Attribute) *Color {
 	c.params = append(c.params, value...)
+	c.leading.Store((*leading)(nil))
 	return c
 }
 
@@ -101,6 +115,7 @@ func (c *Color) prepend(value pencil.Attribute) {
 	c.params = append(c.params, 0)
 	copy(c.params[1:], c.params[0:])
 	c.params[0] = value
+	c.leading.Store((*leading)(nil))
 }
 
 // Set sets the given parameters immediately. It will change the color of
@@ -114,7 +129,7 @@ func Set(code pencil.ColorCode, p ...pencil.Attribute) *Color {
 // Unset resets all escape attributes and clears the output. Usually should
 // be called after Set().
 func Unset() {
-	if pencil.NoColor {
+	if p, _ := pencil.ProfileOf(pencil.Output); p.NoColor {
 		return
 	}
 
@@ -123,37 +138,24 @@ func Unset() {
 
 // Set sets the SGR sequence.
 func (c *Color) Set() *Color {
-	if c.isNoColorSet() {
-		return c
-	}
-
-	fmt.Fprintf(pencil.Output, c.format())
-	return c
+	return c.setWriter(pencil.Output)
 }
 
 func (c *Color) unset() {
-	if c.isNoColorSet() {
-		return
-	}
-
-	Unset()
+	c.unsetWriter(pencil.Output)
 }
 
 func (c *Color) setWriter(w io.Writer) *Color {
-	if c.isNoColorSet() {
+	if c.isNoColorSetFor(w) {
 		return c
 	}
 
-	fmt.Fprintf(w, c.format())
+	fmt.Fprint(w, c.formatFor(w))
 	return c
 }
 
 func (c *Color) unsetWriter(w io.Writer) {
-	if c.isNoColorSet() {
-		return
-	}
-
-	if pencil.NoColor {
+	if c.isNoColorSetFor(w) {
 		return
 	}
 
@@ -192,6 +194,12 @@ func (c *Color) wrap(s string) string {
 // ESC[48;5;<n>m Select background color
 // an example output might be: "38;15;12" -> foreground high-intensity blue
 func (c *Color) sequence() string {
+	return c.sequenceFor(pencil.ModeANSI256)
+}
+
+// sequenceFor is just like sequence with the color converted to mode, e.g.
+// to a basic color for pencil.ModeANSI8
+func (c *Color) sequenceFor(mode pencil.ColorMode) string {
 	var colorfmt string
 	format := make([]string, 0)
 	for _, val := range c.params {
@@ -202,13 +210,13 @@ func (c *Color) sequence() string {
 		}
 		switch val {
 		case pencil.Background:
-			colorfmt, _ = pencil.GetBackground(pencil.SelectColorIndex, c.Code)
+			colorfmt = pencil.IndexSequence(c.Code, true, mode)
 		case pencil.DefaultForeground:
 			colorfmt = pencil.GetDefaultForeground()
 		case pencil.DefaultBackground:
 			colorfmt = pencil.GetDefaultBackground()
 		default: // pencil.Foreground
-			colorfmt, _ = pencil.GetForeground(pencil.SelectColorIndex, c.Code)
+			colorfmt = pencil.IndexSequence(c.Code, false, mode)
 		}
 		format = append(format, colorfmt)
 	}
@@ -244,13 +252,44 @@ func (c *Color) Bg() string {
 	return format
 }
 
+// RGBA implements color.Color. The colors 0-15 (Black ... HiWhite) depend on
+// the terminal and are taken from the current scheme of ansirgb (see
+// ansirgb.SetScheme).
+func (c *Color) RGBA() (r, g, b, a uint32) {
+	return ansirgb.Lookup(int(c.Code)).RGBA()
+}
+
 func (c *Color) format() string {
 	// return fmt.Sprintf("%s[%sm", escape, c.sequence())
-	return c.sequence()
+	return c.formatIn(pencil.ModeANSI256)
+}
+
+// formatFor returns the SGR sequence for the output w, converted to the color
+// mode of w if it carries a color profile
+func (c *Color) formatFor(w io.Writer) string {
+	p, _ := pencil.ProfileOf(w)
+	return c.formatIn(p.Mode)
+}
+
+// formatIn returns sequenceFor(mode); the sequences are computed once and
+// cached until the parameters or the color index change
+func (c *Color) formatIn(mode pencil.ColorMode) string {
+	if mode > pencil.ModeRGB {
+		mode = pencil.ModeRGB
+	}
+	l, _ := c.leading.Load().(*leading)
+	if l == nil || l.code != c.Code {
+		l = &leading{code: c.Code}
+		for m := range l.seq {
+			l.seq[m] = c.sequenceFor(pencil.ColorMode(m))
+		}
+		c.leading.Store(l)
+	}
+	return l.seq[mode]
 }
 
 func (c *Color) unformat() string {
-	return pencil.GetDefaultGround() + pencil.GetRest()
+	return trailing
 }
 
 func (c *Color) isNoColorSet() bool {
@@ -263,13 +302,24 @@ func (c *Color) isNoColorSet() bool {
 	return pencil.NoColor
 }
 
+// isNoColorSetFor is isNoColorSet for the output w, which may carry its own
+// color profile (see pencil.Profiler)
+func (c *Color) isNoColorSetFor(w io.Writer) bool {
+	if c.noColor != nil {
+		return *c.noColor
+	}
+
+	p, _ := pencil.ProfileOf(w)
+	return p.NoColor
+}
+
 func getCachedColor(k pencil.ColorCode) *Color {
 	colorsCacheMu.Lock()
 	defer colorsCacheMu.Unlock()
 
 	c, ok := colorsCache[k]
 	if !ok {
-		c = New(k)
+		c = New(k, pencil.Foreground)
 		colorsCache[k] = c
 	}
 
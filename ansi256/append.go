@@ -0,0 +1,50 @@
+package ansi256
+
+import (
+	"io"
+
+	"github.com/shyang107/pencil/internal/colorbuf"
+)
+
+// AppendSprint is just like Sprint, but appends the colored string to dst
+// and returns the extended buffer.
+func (c *Color) AppendSprint(dst []byte, a ...interface{}) []byte {
+	leading, trailing := c.sequences(c.isNoColorSet(), c.format())
+	return colorbuf.Append(dst, leading, trailing, 0, "", a)
+}
+
+// AppendSprintf is just like Sprintf, but appends the colored string to dst
+// and returns the extended buffer.
+func (c *Color) AppendSprintf(dst []byte, format string, a ...interface{}) []byte {
+	leading, trailing := c.sequences(c.isNoColorSet(), c.format())
+	return colorbuf.Append(dst, leading, trailing, 'f', format, a)
+}
+
+// AppendSprintln is just like Sprintln, but appends the colored string to
+// dst and returns the extended buffer.
+func (c *Color) AppendSprintln(dst []byte, a ...interface{}) []byte {
+	leading, trailing := c.sequences(c.isNoColorSet(), c.format())
+	return colorbuf.Append(dst, leading, trailing, 'l', "", a)
+}
+
+// sequences returns the SGR sequence leading and the reset wrapping the
+// texts of c, both empty if noColor is true
+func (c *Color) sequences(noColor bool, leading string) (string, string) {
+	if noColor {
+		return "", ""
+	}
+	return leading, c.unformat()
+}
+
+// sprint returns the colored text of the operands a formatted by fmt.Sprint
+// (verb 0), fmt.Sprintf (verb 'f') or fmt.Sprintln (verb 'l')
+func (c *Color) sprint(verb byte, format string, a []interface{}) string {
+	leading, trailing := c.sequences(c.isNoColorSet(), c.format())
+	return colorbuf.Sprint(leading, trailing, verb, format, a)
+}
+
+// fprint writes the colored text of sprint for w in a single write
+func (c *Color) fprint(w io.Writer, verb byte, format string, a []interface{}) (int, error) {
+	leading, trailing := c.sequences(c.isNoColorSetFor(w), c.formatFor(w))
+	return colorbuf.Fprint(w, leading, trailing, verb, format, a)
+}
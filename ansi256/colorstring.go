@@ -123,14 +123,16 @@ func ShadeBlueString2(format string, a ...interface{}) string {
 	return colorString(format, pencil.ColorCode(69), a...)
 }
 
-// ShadeGrayString1 retrive a formatted string in another shade of gray
+// ShadeGrayString1 retrive a formatted string in another shade of gray,
+// adapted to the background of the terminal (see ShadeGray1)
 func ShadeGrayString1(format string, a ...interface{}) string {
-	return colorString(format, pencil.ColorCode(59), a...)
+	return adaptiveString(format, ShadeGray1, a...)
 }
 
-// ShadeGrayString2 retrive a formatted string in another shade of gray
+// ShadeGrayString2 retrive a formatted string in another shade of gray,
+// adapted to the background of the terminal (see ShadeGray2)
 func ShadeGrayString2(format string, a ...interface{}) string {
-	return colorString(format, pencil.ColorCode(60), a...)
+	return adaptiveString(format, ShadeGray2, a...)
 }
 
 // Orange is the code of orange in 256-colors
@@ -146,25 +148,29 @@ const (
 	FgGray1 pencil.ColorCode = 238
 	FgGray2 pencil.ColorCode = 243
 	FgGray3 pencil.ColorCode = 248
-	FgGray4 pencil.ColorCode = 258
+	FgGray4 pencil.ColorCode = 253
 )
 
-// GrayString1 retrive a formatted string in Grayscale = 238
+// GrayString1 retrive a formatted string in Grayscale = 238, or 249 on
+// a light background (see Gray1)
 func GrayString1(format string, a ...interface{}) string {
-	return colorString(format, FgGray1, a...)
+	return adaptiveString(format, Gray1, a...)
 }
 
-// GrayString2 retrive a formatted string in Grayscale = 243
+// GrayString2 retrive a formatted string in Grayscale = 243, or 246 on
+// a light background (see Gray2)
 func GrayString2(format string, a ...interface{}) string {
-	return colorString(format, FgGray2, a...)
+	return adaptiveString(format, Gray2, a...)
 }
 
-// GrayString3 retrive a formatted string in Grayscale = 248
+// GrayString3 retrive a formatted string in Grayscale = 248, or 239 on
+// a light background (see Gray3)
 func GrayString3(format string, a ...interface{}) string {
-	return colorString(format, FgGray3, a...)
+	return adaptiveString(format, Gray3, a...)
 }
 
-// GrayString4 retrive a formatted string in Grayscale = 253
+// GrayString4 retrive a formatted string in Grayscale = 253, or 234 on
+// a light background (see Gray4)
 func GrayString4(format string, a ...interface{}) string {
-	return colorString(format, FgGray4, a...)
+	return adaptiveString(format, Gray4, a...)
 }
@@ -10,36 +10,31 @@ import (
 
 // Fprint formats using the default formats for its operands and writes to w.
 // Spaces are added between operands when neither is a string.
-// It returns the number of bytes written and any write error encountered.
+// It returns the number of bytes written, including the color sequences,
+// and any write error encountered.
 // On Windows, users should wrap w with colorable.NewColorable() if w is of
 // type *os.File.
 func (c *Color) Fprint(w io.Writer, a ...interface{}) (n int, err error) {
-	c.setWriter(w)
-	defer c.unsetWriter(w)
-
-	return fmt.Fprint(w, a...)
+	return c.fprint(w, 0, "", a)
 }
 
 // Fprintf formats according to a format specifier and writes to w.
-// It returns the number of bytes written and any write error encountered.
+// It returns the number of bytes written, including the color sequences,
+// and any write error encountered.
 // On Windows, users should wrap w with colorable.NewColorable() if w is of
 // type *os.File.
 func (c *Color) Fprintf(w io.Writer, format string, a ...interface{}) (n int, err error) {
-	c.setWriter(w)
-	defer c.unsetWriter(w)
-
-	return fmt.Fprintf(w, format, a...)
+	return c.fprint(w, 'f', format, a)
 }
 
 // Fprintln formats using the default formats for its operands and writes to w.
 // Spaces are always added between operands and a newline is appended.
+// It returns the number of bytes written, including the color sequences,
+// and any write error encountered.
 // On Windows, users should wrap w with colorable.NewColorable() if w is of
 // type *os.File.
 func (c *Color) Fprintln(w io.Writer, a ...interface{}) (n int, err error) {
-	c.setWriter(w)
-	defer c.unsetWriter(w)
-
-	return fmt.Fprintln(w, a...)
+	return c.fprint(w, 'l', "", a)
 }
 
 // FprintFunc returns a new function that prints the passed arguments as
@@ -68,74 +63,37 @@ func (c *Color) FprintlnFunc() func(w io.Writer, a ...interface{}) {
 
 // FBFprint formats using the default formats for its operands and writes to w.
 // Spaces are added between operands when neither is a string.
-// It returns the number of bytes written and any write error encountered.
+// It returns the number of bytes written, including the color sequences,
+// and any write error encountered.
 // On Windows, users should wrap w with colorable.NewColorable() if w is of
 // type *os.File.
 func FBFprint(w io.Writer, foregroundColor, backgroundColor pencil.ColorCode,
 	a ...interface{}) (n int, err error) {
-	fc := Set(foregroundColor, pencil.Foreground).setWriter(w)
-	bc := Set(backgroundColor, pencil.Background).setWriter(w)
-	defer fc.unsetWriter(w)
-	defer bc.unsetWriter(w)
-
-	// return fmt.Fprint(w, a...)
-
-	if fc.isNoColorSet() {
-		return fmt.Fprint(w, a...)
-	}
-	m := len(a)
-	if a[m-1] == "\n" {
-		a = append(a[:m-1], pencil.GetRest(), "\n")
-		return fmt.Fprint(w, a...)
-	}
-	a = append(a, pencil.GetRest())
-	return fmt.Fprint(w, a...)
+	return fbfprint(w, New(foregroundColor, pencil.Foreground),
+		New(backgroundColor, pencil.Background), fmt.Sprint(a...))
 }
 
 // FBFprintf formats according to a format specifier and writes to w.
-// It returns the number of bytes written and any write error encountered.
+// It returns the number of bytes written, including the color sequences,
+// and any write error encountered.
 // On Windows, users should wrap w with colorable.NewColorable() if w is of
 // type *os.File.
 func FBFprintf(w io.Writer, foregroundColor, backgroundColor pencil.ColorCode,
 	format string, a ...interface{}) (n int, err error) {
-	fc := Set(foregroundColor, pencil.Foreground).setWriter(w)
-	bc := Set(backgroundColor, pencil.Background).setWriter(w)
-	defer fc.unsetWriter(w)
-	defer bc.unsetWriter(w)
-
-	// return fmt.Fprintf(w, format, a...)
-
-	if fc.isNoColorSet() {
-		return fmt.Fprintf(w, format, a...)
-	}
-	fr := strings.TrimRight(format, " ")
-	m := strings.LastIndex(fr, "\n")
-	if m != -1 && m == len(fr)-1 {
-		fr = fr[:m] + pencil.GetRest() + "\n"
-	}
-	fr += strings.Repeat(" ", len(format)-len(fr))
-	return fmt.Fprintf(w, fr, a...)
-
+	return fbfprint(w, New(foregroundColor, pencil.Foreground),
+		New(backgroundColor, pencil.Background), fmt.Sprintf(format, a...))
 }
 
 // FBFprintln formats using the default formats for its operands and writes to w.
 // Spaces are always added between operands and a newline is appended.
+// It returns the number of bytes written, including the color sequences,
+// and any write error encountered.
 // On Windows, users should wrap w with colorable.NewColorable() if w is of
 // type *os.File.
 func FBFprintln(w io.Writer, foregroundColor, backgroundColor pencil.ColorCode,
 	a ...interface{}) (n int, err error) {
-	fc := Set(foregroundColor, pencil.Foreground).setWriter(w)
-	bc := Set(backgroundColor, pencil.Background).setWriter(w)
-	defer fc.unsetWriter(w)
-	defer bc.unsetWriter(w)
-
-	// return fmt.Fprintln(w, a...)
-
-	if fc.isNoColorSet() {
-		return fmt.Fprintln(w, a...)
-	}
-	a = append(a, pencil.GetRest())
-	return fmt.Fprintln(w, a...)
+	return fbfprint(w, New(foregroundColor, pencil.Foreground),
+		New(backgroundColor, pencil.Background), fmt.Sprintln(a...))
 }
 
 // FBFprintFunc returns a new function that prints the passed arguments as
@@ -161,3 +119,20 @@ func FBFprintlnFunc(w io.Writer, foregroundColor, backgroundColor pencil.ColorCo
 		FBFprintln(w, foregroundColor, backgroundColor, a...)
 	}
 }
+
+// fbfprint writes s in the colors fc and bc to w in a single write; the
+// colors are reset before a trailing newline, so the background does not
+// bleed into the next line
+func fbfprint(w io.Writer, fc, bc *Color, s string) (int, error) {
+	if fc.isNoColorSetFor(w) {
+		return io.WriteString(w, s)
+	}
+	leading := fc.formatFor(w) + bc.formatFor(w)
+	t := strings.TrimRight(s, " ")
+	if strings.HasSuffix(t, "\n") {
+		s = leading + t[:len(t)-1] + pencil.GetRest() + "\n" + s[len(t):]
+	} else {
+		s = leading + s + pencil.GetRest()
+	}
+	return io.WriteString(w, s)
+}
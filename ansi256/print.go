@@ -2,43 +2,34 @@ package ansi256
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/shyang107/pencil"
 )
 
 // Print formats using the default formats for its operands and writes to
 // standard output. Spaces are added between operands when neither is a
-// string. It returns the number of bytes written and any write error
-// encountered. This is the standard fmt.Print() method wrapped with the given
+// string. It returns the number of bytes written, including the color
+// sequences, and any write error encountered. This is the standard fmt.Print() method wrapped with the given
 // color.
 func (c *Color) Print(a ...interface{}) (n int, err error) {
-	c.Set()
-	defer c.unset()
-
-	return fmt.Fprint(pencil.Output, a...)
+	return c.fprint(pencil.Output, 0, "", a)
 }
 
 // Printf formats according to a format specifier and writes to standard output.
-// It returns the number of bytes written and any write error encountered.
+// It returns the number of bytes written, including the color sequences,
+// and any write error encountered.
 // This is the standard fmt.Printf() method wrapped with the given color.
 func (c *Color) Printf(format string, a ...interface{}) (n int, err error) {
-	c.Set()
-	defer c.unset()
-
-	return fmt.Fprintf(pencil.Output, format, a...)
+	return c.fprint(pencil.Output, 'f', format, a)
 }
 
 // Println formats using the default formats for its operands and writes to
 // standard output. Spaces are always added between operands and a newline is
-// appended. It returns the number of bytes written and any write error
-// encountered. This is the standard fmt.Print() method wrapped with the given
+// appended. It returns the number of bytes written, including the color
+// sequences, and any write error encountered. This is the standard fmt.Print() method wrapped with the given
 // color.
 func (c *Color) Println(a ...interface{}) (n int, err error) {
-	c.Set()
-	defer c.unset()
-
-	return fmt.Fprintln(pencil.Output, a...)
+	return c.fprint(pencil.Output, 'l', "", a)
 }
 
 // PrintFunc returns a new function that prints the passed arguments as
@@ -67,64 +58,32 @@ func (c *Color) PrintlnFunc() func(a ...interface{}) {
 
 // FBPrint formats using the default formats for its operands and writes to
 // standard output. Spaces are added between operands when neither is a
-// string. It returns the number of bytes written and any write error
-// encountered. This is the standard fmt.Print() method wrapped with the given
+// string. It returns the number of bytes written, including the color
+// sequences, and any write error encountered. This is the standard fmt.Print() method wrapped with the given
 // color.
 func FBPrint(foregroundColor, backgroundColor pencil.ColorCode, a ...interface{}) (n int, err error) {
-	Set(foregroundColor, pencil.Foreground).Set()
-	Set(backgroundColor, pencil.Background).Set()
-	fc := Set(foregroundColor, pencil.Foreground).Set()
-	bc := Set(backgroundColor, pencil.Background).Set()
-	defer fc.unset()
-	defer bc.unset()
-	if fc.isNoColorSet() {
-		return fmt.Fprint(pencil.Output, a...)
-	}
-	m := len(a)
-	if a[m-1] == "\n" {
-		a = append(a[:m-1], pencil.GetRest(), "\n")
-		return fmt.Fprint(pencil.Output, a...)
-	}
-	a = append(a, pencil.GetRest())
-	return fmt.Fprint(pencil.Output, a...)
+	return fbfprint(pencil.Output, New(foregroundColor, pencil.Foreground),
+		New(backgroundColor, pencil.Background), fmt.Sprint(a...))
 }
 
 // FBPrintf formats according to a format specifier and writes to standard output.
-// It returns the number of bytes written and any write error encountered.
+// It returns the number of bytes written, including the color sequences,
+// and any write error encountered.
 // This is the standard fmt.Printf() method wrapped with the given color.
 func FBPrintf(foregroundColor, backgroundColor pencil.ColorCode,
 	format string, a ...interface{}) (n int, err error) {
-	fc := Set(foregroundColor, pencil.Foreground).Set()
-	bc := Set(backgroundColor, pencil.Background).Set()
-	defer fc.unset()
-	defer bc.unset()
-	if fc.isNoColorSet() {
-		return fmt.Fprintf(pencil.Output, format, a...)
-	}
-	fr := strings.TrimRight(format, " ")
-	m := strings.LastIndex(fr, "\n")
-	if m != -1 && m == len(fr)-1 {
-		fr = fr[:m] + pencil.GetRest() + "\n"
-	}
-	fr += strings.Repeat(" ", len(format)-len(fr))
-	return fmt.Fprintf(pencil.Output, fr, a...)
+	return fbfprint(pencil.Output, New(foregroundColor, pencil.Foreground),
+		New(backgroundColor, pencil.Background), fmt.Sprintf(format, a...))
 }
 
 // FBPrintln formats using the default formats for its operands and writes to
 // standard output. Spaces are always added between operands and a newline is
-// appended. It returns the number of bytes written and any write error
-// encountered. This is the standard fmt.Print() method wrapped with the given
+// appended. It returns the number of bytes written, including the color
+// sequences, and any write error encountered. This is the standard fmt.Print() method wrapped with the given
 // color.
 func FBPrintln(foregroundColor, backgroundColor pencil.ColorCode, a ...interface{}) (n int, err error) {
-	fc := Set(foregroundColor, pencil.Foreground).Set()
-	bc := Set(backgroundColor, pencil.Background).Set()
-	defer fc.unset()
-	defer bc.unset()
-	if fc.isNoColorSet() {
-		return fmt.Fprintln(pencil.Output, a...)
-	}
-	a = append(a, pencil.GetRest())
-	return fmt.Fprintln(pencil.Output, a...)
+	return fbfprint(pencil.Output, New(foregroundColor, pencil.Foreground),
+		New(backgroundColor, pencil.Background), fmt.Sprintln(a...))
 }
 
 // FBPrintFunc returns a new function that prints the passed arguments as
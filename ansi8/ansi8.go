@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/shyang107/pencil"
 	// "github.com/shyang107/go-twinvoices/util"
@@ -18,6 +19,8 @@ var (
 	colorCache   = make(map[pencil.Attribute]*Color)
 	colorCacheMu sync.Mutex // protects colorsCache
 
+	// trailing resets the colors after a colored text
+	trailing = pencil.GetDefaultGround() + pencil.GetRest()
 )
 
 // Color is a alias of "color.Color"
@@ -25,6 +28,7 @@ type Color struct {
 	// color.Color
 	params  []pencil.Attribute
 	noColor *bool
+	leading atomic.Value // string, the cached SGR sequence (see format)
 }
 
 //---------------------------------------------------------
@@ -91,6 +95,7 @@ func New(value ...pencil.Attribute) *Color {
 // and create custom color objects. Example: Add(color.FgRed, color.Underline).
 func (c *Color) Add(value ...pencil.Attribute) *Color {
 	c.params = append(c.params, value...)
+	c.leading.Store("")
 	return c
 }
 
@@ -98,6 +103,7 @@ func (c *Color) prepend(value pencil.Attribute) {
 	c.params = append(c.params, 0)
 	copy(c.params[1:], c.params[0:])
 	c.params[0] = value
+	c.leading.Store("")
 }
 
 // Set sets the given parameters immediately. It will change the color of
@@ -111,7 +117,7 @@ func Set(p ...pencil.Attribute) *Color {
 // Unset resets all escape attributes and clears the output. Usually should
 // be called after Set().
 func Unset() {
-	if pencil.NoColor {
+	if p, _ := pencil.ProfileOf(pencil.Output); p.NoColor {
 		return
 	}
 
@@ -120,37 +126,24 @@ func Unset() {
 
 // Set sets the SGR sequence.
 func (c *Color) Set() *Color {
-	if c.isNoColorSet() {
-		return c
-	}
-
-	fmt.Fprintf(pencil.Output, c.format())
-	return c
+	return c.setWriter(pencil.Output)
 }
 
 func (c *Color) unset() {
-	if c.isNoColorSet() {
-		return
-	}
-
-	Unset()
+	c.unsetWriter(pencil.Output)
 }
 
 func (c *Color) setWriter(w io.Writer) *Color {
-	if c.isNoColorSet() {
+	if c.isNoColorSetFor(w) {
 		return c
 	}
 
-	fmt.Fprintf(w, c.format())
+	fmt.Fprint(w, c.format())
 	return c
 }
 
 func (c *Color) unsetWriter(w io.Writer) {
-	if c.isNoColorSet() {
-		return
-	}
-
-	if pencil.NoColor {
+	if c.isNoColorSetFor(w) {
 		return
 	}
 
@@ -182,12 +175,25 @@ func (c *Color) sequence() string {
 	return strings.Join(format, ";")
 }
 
+// format returns the SGR sequence of the parameters; it is computed once and
+// cached until the parameters change
 func (c *Color) format() string {
-	return fmt.Sprintf("%s[%sm", pencil.Escape, c.sequence())
+	if f, _ := c.leading.Load().(string); f != "" {
+		return f
+	}
+	f := fmt.Sprintf("%s[%sm", pencil.Escape, c.sequence())
+	c.leading.Store(f)
+	return f
+}
+
+// formatFor returns the SGR sequence for the output w: the basic colors are
+// supported by every color mode
+func (c *Color) formatFor(w io.Writer) string {
+	return c.format()
 }
 
 func (c *Color) unformat() string {
-	return pencil.GetDefaultGround() + pencil.GetRest()
+	return trailing
 }
 
 func (c *Color) isNoColorSet() bool {
@@ -200,6 +206,17 @@ func (c *Color) isNoColorSet() bool {
 	return pencil.NoColor
 }
 
+// isNoColorSetFor is isNoColorSet for the output w, which may carry its own
+// color profile (see pencil.Profiler)
+func (c *Color) isNoColorSetFor(w io.Writer) bool {
+	if c.noColor != nil {
+		return *c.noColor
+	}
+
+	p, _ := pencil.ProfileOf(w)
+	return p.NoColor
+}
+
 func getCachedColor(p pencil.Attribute) *Color {
 	colorCacheMu.Lock()
 	defer colorCacheMu.Unlock()
@@ -1,42 +1,34 @@
 package ansi8
 
-import (
-	"fmt"
-	"io"
-)
+import "io"
 
 // Fprint formats using the default formats for its operands and writes to w.
 // Spaces are added between operands when neither is a string.
-// It returns the number of bytes written and any write error encountered.
+// It returns the number of bytes written, including the color sequences,
+// and any write error encountered.
 // On Windows, users should wrap w with colorable.NewColorable() if w is of
 // type *os.File.
 func (c *Color) Fprint(w io.Writer, a ...interface{}) (n int, err error) {
-	c.setWriter(w)
-	defer c.unsetWriter(w)
-
-	return fmt.Fprint(w, a...)
+	return c.fprint(w, 0, "", a)
 }
 
 // Fprintf formats according to a format specifier and writes to w.
-// It returns the number of bytes written and any write error encountered.
+// It returns the number of bytes written, including the color sequences,
+// and any write error encountered.
 // On Windows, users should wrap w with colorable.NewColorable() if w is of
 // type *os.File.
 func (c *Color) Fprintf(w io.Writer, format string, a ...interface{}) (n int, err error) {
-	c.setWriter(w)
-	defer c.unsetWriter(w)
-
-	return fmt.Fprintf(w, format, a...)
+	return c.fprint(w, 'f', format, a)
 }
 
 // Fprintln formats using the default formats for its operands and writes to w.
 // Spaces are always added between operands and a newline is appended.
+// It returns the number of bytes written, including the color sequences,
+// and any write error encountered.
 // On Windows, users should wrap w with colorable.NewColorable() if w is of
 // type *os.File.
 func (c *Color) Fprintln(w io.Writer, a ...interface{}) (n int, err error) {
-	c.setWriter(w)
-	defer c.unsetWriter(w)
-
-	return fmt.Fprintln(w, a...)
+	return c.fprint(w, 'l', "", a)
 }
 
 // FprintFunc returns a new function that prints the passed arguments as
@@ -1,43 +1,31 @@
 package ansi8
 
-import (
-	"fmt"
-
-	"github.com/shyang107/pencil"
-)
+import "github.com/shyang107/pencil"
 
 // Print formats using the default formats for its operands and writes to
 // standard output. Spaces are added between operands when neither is a
-// string. It returns the number of bytes written and any write error
-// encountered. This is the standard fmt.Print() method wrapped with the given
+// string. It returns the number of bytes written, including the color
+// sequences, and any write error encountered. This is the standard fmt.Print() method wrapped with the given
 // color.
 func (c *Color) Print(a ...interface{}) (n int, err error) {
-	c.Set()
-	defer c.unset()
-
-	return fmt.Fprint(pencil.Output, a...)
+	return c.fprint(pencil.Output, 0, "", a)
 }
 
 // Printf formats according to a format specifier and writes to standard output.
-// It returns the number of bytes written and any write error encountered.
+// It returns the number of bytes written, including the color sequences,
+// and any write error encountered.
 // This is the standard fmt.Printf() method wrapped with the given color.
 func (c *Color) Printf(format string, a ...interface{}) (n int, err error) {
-	c.Set()
-	defer c.unset()
-
-	return fmt.Fprintf(pencil.Output, format, a...)
+	return c.fprint(pencil.Output, 'f', format, a)
 }
 
 // Println formats using the default formats for its operands and writes to
 // standard output. Spaces are always added between operands and a newline is
-// appended. It returns the number of bytes written and any write error
-// encountered. This is the standard fmt.Print() method wrapped with the given
+// appended. It returns the number of bytes written, including the color
+// sequences, and any write error encountered. This is the standard fmt.Print() method wrapped with the given
 // color.
 func (c *Color) Println(a ...interface{}) (n int, err error) {
-	c.Set()
-	defer c.unset()
-
-	return fmt.Fprintln(pencil.Output, a...)
+	return c.fprint(pencil.Output, 'l', "", a)
 }
 
 // PrintFunc returns a new function that prints the passed arguments as
@@ -1,20 +1,18 @@
 package ansi8
 
-import "fmt"
-
 // Sprint is just like Print, but returns a string instead of printing it.
 func (c *Color) Sprint(a ...interface{}) string {
-	return c.wrap(fmt.Sprint(a...))
+	return c.sprint(0, "", a)
 }
 
 // Sprintln is just like Println, but returns a string instead of printing it.
 func (c *Color) Sprintln(a ...interface{}) string {
-	return c.wrap(fmt.Sprintln(a...))
+	return c.sprint('l', "", a)
 }
 
 // Sprintf is just like Printf, but returns a string instead of printing it.
 func (c *Color) Sprintf(format string, a ...interface{}) string {
-	return c.wrap(fmt.Sprintf(format, a...))
+	return c.sprint('f', format, a)
 }
 
 // SprintFunc returns a new function that returns colorized strings for the
@@ -25,7 +23,7 @@ func (c *Color) Sprintf(format string, a ...interface{}) string {
 //	fmt.Fprintf(color.Output, "This is a %s", put("warning"))
 func (c *Color) SprintFunc() func(a ...interface{}) string {
 	return func(a ...interface{}) string {
-		return c.wrap(fmt.Sprint(a...))
+		return c.sprint(0, "", a)
 	}
 }
 
@@ -34,7 +32,7 @@ func (c *Color) SprintFunc() func(a ...interface{}) string {
 // string. Windows users should use this in conjunction with color.Output.
 func (c *Color) SprintfFunc() func(format string, a ...interface{}) string {
 	return func(format string, a ...interface{}) string {
-		return c.wrap(fmt.Sprintf(format, a...))
+		return c.sprint('f', format, a)
 	}
 }
 
@@ -43,6 +41,6 @@ func (c *Color) SprintfFunc() func(format string, a ...interface{}) string {
 // string. Windows users should use this in conjunction with color.Output.
 func (c *Color) SprintlnFunc() func(a ...interface{}) string {
 	return func(a ...interface{}) string {
-		return c.wrap(fmt.Sprintln(a...))
+		return c.sprint('l', "", a)
 	}
 }
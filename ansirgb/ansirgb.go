@@ -105,7 +105,7 @@ func next(n, start, step uint8) uint8 {
 
 // Convert returns the ANSI color closest to c.
 func Convert(c color.Color) *Color {
-	return Palette.Convert(c).(*Color)
+	return CurrentPalette().Convert(c).(*Color)
 }
 
 // Index returns the index of the ANSI palette color closest to c
@@ -0,0 +1,153 @@
+package ansirgb
+
+import (
+	"image/color"
+	"sync"
+)
+
+// Scheme describes the colors a terminal actually uses for the ANSI colors
+// 0-15 and, optionally, its default foreground and background colors.
+type Scheme struct {
+	Name string
+	// ANSI contains the colors of code 0-7 (standard) and 8-15 (high-intensity)
+	ANSI [16]color.RGBA
+	// Foreground and Background are the default colors of the terminal; nil
+	// if unknown
+	Foreground color.Color
+	Background color.Color
+}
+
+// IsDark returns true if the default background of the scheme is dark; if the
+// background is unknown, the color of code 0 (black) is used instead.
+func (s *Scheme) IsDark() bool {
+	bg := s.Background
+	if bg == nil {
+		bg = s.ANSI[0]
+	}
+	return Luminance(bg) < 0.5
+}
+
+// Luminance returns the relative luminance (0: black, 1: white) of c
+func Luminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	return (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)) / 0xffff
+}
+
+// XtermScheme is the default scheme of xterm, which is assumed for the
+// colors 0-15 as long as no other scheme is set by SetScheme().
+var XtermScheme = &Scheme{
+	Name: "xterm",
+	ANSI: [16]color.RGBA{
+		{0x00, 0x00, 0x00, 0xff}, {0xcd, 0x00, 0x00, 0xff}, {0x00, 0xcd, 0x00, 0xff}, {0xcd, 0xcd, 0x00, 0xff},
+		{0x00, 0x00, 0xee, 0xff}, {0xcd, 0x00, 0xcd, 0xff}, {0x00, 0xcd, 0xcd, 0xff}, {0xe5, 0xe5, 0xe5, 0xff},
+		{0x7f, 0x7f, 0x7f, 0xff}, {0xff, 0x00, 0x00, 0xff}, {0x00, 0xff, 0x00, 0xff}, {0xff, 0xff, 0x00, 0xff},
+		{0x5c, 0x5c, 0xff, 0xff}, {0xff, 0x00, 0xff, 0xff}, {0x00, 0xff, 0xff, 0xff}, {0xff, 0xff, 0xff, 0xff},
+	},
+}
+
+var (
+	// basePalette is the original Palette without the colors 0-15
+	basePalette = Palette
+
+	// Basic is a palette of the 16 basic ANSI colors (code 0-15) of the
+	// current scheme; it is used to convert colors for 16-colors terminals.
+	// It is replaced by SetScheme, so code running concurrently with it
+	// should use CurrentBasic() instead.
+	Basic = createBasic(XtermScheme)
+
+	scheme      = XtermScheme
+	schemeKnown bool
+	schemeMu    sync.RWMutex // protects scheme, schemeKnown, Palette and Basic
+)
+
+func createBasic(s *Scheme) color.Palette {
+	p := make(color.Palette, 16)
+	for i := range s.ANSI {
+		rgba := s.ANSI[i]
+		p[i] = &Color{&rgba, i}
+	}
+	return p
+}
+
+// SetScheme sets the colors the terminal uses for code 0-15. Once a scheme is
+// set, these colors become part of Palette, so Convert() and Index() may
+// return them; nil restores XtermScheme and removes them from Palette again.
+//
+// The conversions of the package, CurrentPalette() and CurrentBasic() are
+// safe to use concurrently with SetScheme; reading the variables Palette and
+// Basic directly is not.
+func SetScheme(s *Scheme) {
+	schemeMu.Lock()
+	defer schemeMu.Unlock()
+
+	schemeKnown = s != nil
+	if s == nil {
+		s = XtermScheme
+	}
+	scheme = s
+	Basic = createBasic(s)
+	if !schemeKnown {
+		Palette = basePalette
+		return
+	}
+	p := make(color.Palette, 0, len(basePalette)+len(Basic))
+	p = append(p, basePalette...)
+	Palette = append(p, Basic...)
+}
+
+// CurrentPalette returns Palette, the colors 0-15 included if a scheme is set
+func CurrentPalette() color.Palette {
+	schemeMu.RLock()
+	defer schemeMu.RUnlock()
+
+	return Palette
+}
+
+// CurrentBasic returns Basic, the colors 0-15 of the current scheme
+func CurrentBasic() color.Palette {
+	schemeMu.RLock()
+	defer schemeMu.RUnlock()
+
+	return Basic
+}
+
+// CurrentScheme returns the scheme set by SetScheme(), or XtermScheme; known
+// is false if no scheme has been set, i.e. the colors 0-15 are assumed.
+func CurrentScheme() (s *Scheme, known bool) {
+	schemeMu.RLock()
+	defer schemeMu.RUnlock()
+
+	return scheme, schemeKnown
+}
+
+// Lookup returns the RGB color of the 256-colors code; code 0-15 are taken
+// from the current scheme.
+func Lookup(code int) color.RGBA {
+	switch {
+	case code < 0:
+		return color.RGBA{}
+	case code < 16:
+		s, _ := CurrentScheme()
+		return s.ANSI[code]
+	case code < 232:
+		code -= 16
+		return color.RGBA{cubeLevel(code / 36), cubeLevel(code / 6 % 6), cubeLevel(code % 6), 0xff}
+	case code < 256:
+		v := uint8(8 + 10*(code-232))
+		return color.RGBA{v, v, v, 0xff}
+	default:
+		return color.RGBA{}
+	}
+}
+
+func cubeLevel(n int) uint8 {
+	if n == 0 {
+		return 0
+	}
+	return uint8(55 + 40*n)
+}
+
+// ConvertBasic returns the basic ANSI color (code 0-15) closest to c
+func ConvertBasic(c color.Color) *Color {
+	return CurrentBasic().Convert(c).(*Color)
+}
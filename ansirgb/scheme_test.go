@@ -0,0 +1,55 @@
+package ansirgb
+
+import (
+	"image/color"
+	"sync"
+	"testing"
+)
+
+// TestSetSchemeConcurrent is meant for the race detector: the conversions
+// run while the scheme changes
+func TestSetSchemeConcurrent(t *testing.T) {
+	defer SetScheme(nil)
+
+	light := *XtermScheme
+	light.Name = "light"
+	light.ANSI[0] = color.RGBA{0xff, 0xff, 0xff, 0xff}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				c := color.RGBA{uint8(i * 30), uint8(j), 0x80, 0xff}
+				if code := Index(c); code < 0 || code > 255 {
+					t.Errorf("Index(%v) = %d", c, code)
+				}
+				ConvertBasic(c)
+			}
+		}(i)
+	}
+	for j := 0; j < 50; j++ {
+		if j%2 == 0 {
+			SetScheme(&light)
+		} else {
+			SetScheme(nil)
+		}
+	}
+	wg.Wait()
+}
+
+func TestSetScheme(t *testing.T) {
+	defer SetScheme(nil)
+
+	if n := len(CurrentPalette()); n != 241 {
+		t.Fatalf("len(CurrentPalette()) = %d, want 241", n)
+	}
+	SetScheme(XtermScheme)
+	if n := len(CurrentPalette()); n != 257 {
+		t.Errorf("len(CurrentPalette()) with a scheme = %d, want 257", n)
+	}
+	if code := Index(color.RGBA{0xcd, 0, 0, 0xff}); code != 1 {
+		t.Errorf("Index(xterm red) = %d, want 1", code)
+	}
+}
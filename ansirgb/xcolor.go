@@ -0,0 +1,41 @@
+package ansirgb
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// ParseXColor parses the X11 color forms "rgb:r/g/b", with 1-4 hex digits
+// per channel, e.g. "rgb:ffff/8888/0000", and "rgba:r/g/b/a", whose alpha is
+// ignored, as found in Xresources and in the color replies of terminals
+func ParseXColor(s string) (color.RGBA, error) {
+	var channels []string
+	lower := strings.ToLower(s)
+	switch {
+	case strings.HasPrefix(lower, "rgb:"):
+		channels = strings.Split(lower[len("rgb:"):], "/")
+	case strings.HasPrefix(lower, "rgba:"):
+		channels = strings.Split(lower[len("rgba:"):], "/")
+		if len(channels) == 4 {
+			channels = channels[:3]
+		}
+	}
+	if len(channels) != 3 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	var v [3]uint8
+	for i, h := range channels {
+		if len(h) < 1 || len(h) > 4 {
+			return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+		}
+		n, err := strconv.ParseUint(h, 16, 16)
+		if err != nil {
+			return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+		}
+		max := uint64(1)<<(4*uint(len(h))) - 1
+		v[i] = uint8((n*0xff + max/2) / max)
+	}
+	return color.RGBA{v[0], v[1], v[2], 0xff}, nil
+}
@@ -0,0 +1,57 @@
+package pencil
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+// DarkBackground overrides the detection of BackgroundIsDark() if not nil,
+// e.g. for a "--light" flag:
+//
+//	pencil.DarkBackground = pencil.BoolPtr(false)
+var DarkBackground *bool
+
+// BackgroundIsDark reports whether the terminal has a dark background. The
+// first of the following that is known decides:
+//
+//  1. DarkBackground, if not nil
+//  2. the environment variable PENCIL_BACKGROUND: "dark" or "light"
+//  3. the background reported by the terminal to QueryPalette() (OSC 11)
+//  4. the environment variable COLORFGBG, e.g. "15;0" (set by rxvt, Konsole, ...)
+//
+// Otherwise the background is assumed to be dark.
+func BackgroundIsDark() bool {
+	if DarkBackground != nil {
+		return *DarkBackground
+	}
+	switch strings.ToLower(os.Getenv("PENCIL_BACKGROUND")) {
+	case "dark":
+		return true
+	case "light":
+		return false
+	}
+	if s := QueriedPalette(); s != nil && s.Background != nil {
+		return ansirgb.Luminance(s.Background) < 0.5
+	}
+	if dark, ok := colorFgBgIsDark(os.Getenv("COLORFGBG")); ok {
+		return dark
+	}
+	return true
+}
+
+// colorFgBgIsDark reports whether the background of COLORFGBG ("fg;bg" or
+// "fg;default;bg") is dark: the colors 0-6 and 8 are dark backgrounds
+func colorFgBgIsDark(v string) (dark, ok bool) {
+	if v == "" {
+		return false, false
+	}
+	fields := strings.Split(v, ";")
+	bg, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || bg < 0 || bg > 15 {
+		return false, false
+	}
+	return bg <= 6 || bg == 8, true
+}
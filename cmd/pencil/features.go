@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/termimg"
+)
+
+// runTest prints what the terminal is detected to support and samples of
+// the attributes and colors, so the user can see what it renders
+func runTest(p pencil.Profile, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+	var b strings.Builder
+	modes := []string{pencil.ModeANSI8: "16 colors", pencil.ModeANSI256: "256 colors", pencil.ModeRGB: "truecolor"}
+	fmt.Fprintf(&b, "TERM=%q COLORTERM=%q\n", os.Getenv("TERM"), os.Getenv("COLORTERM"))
+	fmt.Fprintf(&b, "colors: %v, mode: %s, dark background: %v, hyperlinks: %v, images: %s\n\n",
+		!p.NoColor, modes[p.Mode], pencil.BackgroundIsDark(), p.Hyperlinks && !p.NoColor, termimg.DetectProtocol())
+
+	b.WriteString("attributes:\n")
+	for _, a := range []pencil.Attribute{
+		pencil.Bold, pencil.Faint, pencil.Italic, pencil.Underline, pencil.BlinkSlow,
+		pencil.ReverseVideo, pencil.Concealed, pencil.CrossedOut,
+	} {
+		name := pencil.AttributeName(a)
+		fmt.Fprintf(&b, "  %-10s %s\n", name, pencil.NewStyle(nil, a).SprintFor(p, "The quick brown fox"))
+	}
+
+	b.WriteString("\n16 colors:\n  ")
+	for i := 0; i < 16; i++ {
+		b.WriteString(pencil.Style{Bg: pencil.ANSIColor(pencil.ColorCode(i))}.SprintFor(p, "   "))
+		if i == 7 {
+			b.WriteString("\n  ")
+		}
+	}
+
+	// the 256 and 24-bit colors are written in their modes, so the
+	// difference shows if the terminal supports them
+	b.WriteString("\n\n256 colors:\n  ")
+	for i := 16; i < 232; i += 6 {
+		b.WriteString(pencil.Style{Bg: pencil.IndexColor(pencil.ColorCode(i + 3))}.SprintFor(inMode(p, pencil.ModeANSI256), " "))
+	}
+	b.WriteString("\n\ntruecolor:\n  ")
+	for i := 0; i < 72; i++ {
+		v := uint8(i * 255 / 71)
+		b.WriteString(pencil.Style{Bg: pencil.RGBColor(v, 0x40, 0xff-v)}.SprintFor(inMode(p, pencil.ModeRGB), " "))
+	}
+	b.WriteString("\n\nunicode:\n  box ┌─┬─┐ blocks ▀▄█▚ braille ⣿⡇ wide 漢字 emoji 🎨\n")
+	b.WriteString("\nhyperlink:\n  " + pencil.LinkFor(p, "https://github.com/shyang107/pencil", "pencil on GitHub") + "\n")
+	fmt.Fprint(stdout, b.String())
+	return nil
+}
+
+// inMode returns p with the color mode mode
+func inMode(p pencil.Profile, mode pencil.ColorMode) pencil.Profile {
+	p.Mode = mode
+	return p
+}
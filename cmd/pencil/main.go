@@ -0,0 +1,132 @@
+// Command pencil previews the colors of the terminal and the conversions of
+// package pencil:
+//
+//	pencil palette 256      the 256 colors with their codes
+//	pencil palette svg      the SVG color names with their nearest 256-color
+//	pencil show "#ff8800"   a color converted to every color mode
+//	pencil test             the attributes and colors the terminal supports
+//
+// and colors text in shell scripts:
+//
+//	pencil style "bold red" -- "text"
+//	tail -f app.log | pencil paint -match ERROR -style "white on red"
+//
+// The colors follow the terminal (see pencil.DetectProfile), including
+// NO_COLOR, unless set by the flags -color and -mode. The styles are the
+// names of the theme, e.g. "error", loaded from the file of the flag -theme
+// or of $PENCIL_THEME (see package theme), or textual styles such as
+// "italic #ff8800 on 236".
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/theme"
+)
+
+// command is a subcommand of pencil
+type command struct {
+	name  string
+	args  string
+	usage string
+	run   func(p pencil.Profile, args []string) error
+}
+
+var commands = []command{
+	{"palette", "[16|256|svg]", "print a palette (default 256)", runPalette},
+	{"show", "color...", "print a color converted to every color mode", runShow},
+	{"test", "", "print the attributes and colors the terminal supports", runTest},
+	{"style", "[-n] [-link url] style [--] [text...]", "print the text, or the lines of stdin, in a style", runStyle},
+	{"paint", "[-line] [-style s] -match re -style s...", "style the matches of re in the lines of stdin", runPaint},
+}
+
+// errUsage is returned by a command called with invalid arguments
+var errUsage = fmt.Errorf("invalid arguments")
+
+// stdout is where the commands write
+var stdout io.Writer = os.Stdout
+
+func main() {
+	flag.Usage = usage
+	colorFlag := flag.String("color", "auto", "colorize the output: auto, always or never")
+	modeFlag := flag.String("mode", "auto", "color mode: auto, 16, 256 or truecolor")
+	themeFlag := flag.String("theme", os.Getenv("PENCIL_THEME"), "theme file of the style names (default $PENCIL_THEME)")
+	flag.Parse()
+
+	if *themeFlag != "" {
+		if err := theme.Use(*themeFlag); err != nil {
+			fmt.Fprintln(os.Stderr, "pencil:", err)
+			os.Exit(2)
+		}
+	}
+
+	p, err := profile(*colorFlag, *modeFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pencil:", err)
+		os.Exit(2)
+	}
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	name, args := flag.Arg(0), flag.Args()[1:]
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		switch err := c.run(p, args); {
+		case err == errUsage:
+			fmt.Fprintf(os.Stderr, "usage: pencil %s %s\n", c.name, c.args)
+			os.Exit(2)
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "pencil:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "pencil: unknown command %q\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintln(w, "usage: pencil [flags] command [arguments]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-46s %s\n", strings.TrimSpace(c.name+" "+c.args), c.usage)
+	}
+	fmt.Fprintln(w, "\nflags:")
+	flag.PrintDefaults()
+}
+
+// profile returns the color profile of the output set by the flags
+func profile(colorFlag, modeFlag string) (pencil.Profile, error) {
+	p := pencil.DetectProfile(os.Stdout)
+	switch strings.ToLower(colorFlag) {
+	case "auto":
+	case "always", "force":
+		p.NoColor = false
+	case "never", "none":
+		p.NoColor = true
+	default:
+		return p, fmt.Errorf("invalid -color %q: want auto, always or never", colorFlag)
+	}
+	switch strings.ToLower(modeFlag) {
+	case "auto":
+	case "16", "8", "ansi":
+		p.Mode = pencil.ModeANSI8
+	case "256":
+		p.Mode = pencil.ModeANSI256
+	case "truecolor", "24bit", "rgb":
+		p.Mode = pencil.ModeRGB
+	default:
+		return p, fmt.Errorf("invalid -mode %q: want auto, 16, 256 or truecolor", modeFlag)
+	}
+	return p, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/ansirgb"
+	"github.com/shyang107/pencil/rgb16b"
+)
+
+func runPalette(p pencil.Profile, args []string) error {
+	which := "256"
+	switch len(args) {
+	case 0:
+	case 1:
+		which = args[0]
+	default:
+		return errUsage
+	}
+	switch which {
+	case "16", "8":
+		printBasic(p)
+	case "256":
+		print256(p)
+	case "svg":
+		printSVG(p)
+	default:
+		return errUsage
+	}
+	return nil
+}
+
+// swatch returns text on the background bg, in black or white whichever is
+// the more readable
+func swatch(p pencil.Profile, bg *pencil.ColorSpec, text string) string {
+	fg := pencil.ANSIColor(15)
+	if ansirgb.Luminance(bg) > 0.5 {
+		fg = pencil.ANSIColor(0)
+	}
+	return pencil.Style{Fg: fg, Bg: bg}.SprintFor(p, text)
+}
+
+// printBasic prints the colors 0-15 with their names
+func printBasic(p pencil.Profile) {
+	var b strings.Builder
+	for row := 0; row < 2; row++ {
+		for i := 8 * row; i < 8*row+8; i++ {
+			code := pencil.ColorCode(i)
+			b.WriteString(swatch(p, pencil.ANSIColor(code), fmt.Sprintf(" %-10s", pencil.ANSIColor(code))))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprint(stdout, b.String())
+}
+
+// print256 prints the 256 colors: the basic colors, the color cube in two
+// halves of three red levels and the grays
+func print256(p pencil.Profile) {
+	var b strings.Builder
+	cell := func(code int) {
+		b.WriteString(swatch(p, pencil.IndexColor(pencil.ColorCode(code)), fmt.Sprintf(" %3d", code)))
+	}
+	for i := 0; i < 16; i++ {
+		cell(i)
+		if i%8 == 7 {
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\n")
+	for half := 0; half < 2; half++ {
+		for g := 0; g < 6; g++ {
+			for r := 3 * half; r < 3*half+3; r++ {
+				for bl := 0; bl < 6; bl++ {
+					cell(16 + 36*r + 6*g + bl)
+				}
+				b.WriteString(" ")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	for i := 232; i < 256; i++ {
+		cell(i)
+		if i%12 == 3 { // 243 and 255
+			b.WriteString("\n")
+		}
+	}
+	fmt.Fprint(stdout, b.String())
+}
+
+// printSVG prints the SVG colors of rgb16b.Map with their nearest 256-color
+func printSVG(p pencil.Profile) {
+	width := 0
+	for _, name := range rgb16b.Names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	var b strings.Builder
+	for _, name := range rgb16b.Names {
+		rgba := color.RGBAModel.Convert(rgb16b.Map[name]).(color.RGBA)
+		spec := pencil.RGBColor(rgba.R, rgba.G, rgba.B)
+		code := ansirgb.Index(rgba)
+		fmt.Fprintf(&b, "%s %-*s %s  %s %3d\n", swatch(p, spec, "      "), width, name, spec,
+			swatch(p, pencil.IndexColor(pencil.ColorCode(code)), "      "), code)
+	}
+	fmt.Fprint(stdout, b.String())
+}
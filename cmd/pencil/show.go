@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/ansirgb"
+	"github.com/shyang107/pencil/rgb16b"
+	"github.com/shyang107/pencil/theme"
+)
+
+func runShow(p pencil.Profile, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	var b strings.Builder
+	for i, arg := range args {
+		c, err := theme.ParseColor(arg)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		show(&b, p, arg, c)
+	}
+	fmt.Fprint(stdout, b.String())
+	return nil
+}
+
+// show writes the color c, parsed from arg, in each color mode; each swatch
+// is written in its mode, so they can be compared
+func show(b *strings.Builder, p pencil.Profile, arg string, c *pencil.ColorSpec) {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	rgb := pencil.RGBColor(rgba.R, rgba.G, rgba.B)
+	line := func(label string, spec *pencil.ColorSpec, mode pencil.ColorMode, detail string) {
+		sw := pencil.Style{Bg: spec}.SprintFor(inMode(p, mode), "        ")
+		fmt.Fprintf(b, "  %-10s %s %-22q %s\n", label, sw, spec.Sequence(false), detail)
+	}
+
+	fmt.Fprintf(b, "%s\n", arg)
+	line("truecolor", rgb, pencil.ModeRGB, fmt.Sprintf("%s  rgb(%d, %d, %d)", rgb, rgba.R, rgba.G, rgba.B))
+	c256 := rgb.In(pencil.ModeANSI256)
+	if c.Mode != pencil.ModeRGB {
+		c256 = pencil.IndexColor(c.Code)
+	}
+	l := ansirgb.Lookup(int(c256.Code))
+	line("256", c256, pencil.ModeANSI256, fmt.Sprintf("%s  %s", c256, pencil.RGBColor(l.R, l.G, l.B)))
+	c16 := c.In(pencil.ModeANSI8)
+	line("16", c16, pencil.ModeANSI8, fmt.Sprintf("%s  %d", c16, c16.Code))
+	name, d := nearestSVG(rgba)
+	sv := rgb16b.Map[name]
+	sr, sg, sb, _ := sv.RGBA()
+	svg := pencil.RGBColor(uint8(sr>>8), uint8(sg>>8), uint8(sb>>8))
+	detail := name
+	if d > 0 {
+		detail = fmt.Sprintf("%s  %s (nearest)", name, svg)
+	}
+	line("svg", svg, pencil.ModeRGB, detail)
+}
+
+// nearestSVG returns the SVG color name closest to c, with its distance
+func nearestSVG(c color.RGBA) (name string, distance float64) {
+	distance = math.Inf(1)
+	for _, n := range rgb16b.Names {
+		r, g, b, _ := rgb16b.Map[n].RGBA()
+		dr, dg, db := float64(r>>8)-float64(c.R), float64(g>>8)-float64(c.G), float64(b>>8)-float64(c.B)
+		if d := math.Sqrt(dr*dr + dg*dg + db*db); d < distance {
+			name, distance = n, d
+		}
+	}
+	return name, distance
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/theme"
+)
+
+// stdin is what the commands read
+var stdin io.Reader = os.Stdin
+
+// lookupStyle returns the style named name in the current theme, e.g.
+// "error", or else the style of the textual form name, e.g. "bold red"
+func lookupStyle(name string) (pencil.Style, error) {
+	if s, ok := pencil.CurrentTheme().Lookup(name); ok {
+		return s, nil
+	}
+	return theme.ParseStyle(name)
+}
+
+// runStyle prints its arguments, or the lines of stdin, in a style:
+//
+//	pencil style "bold red" -- "text"
+//	pencil style error < messages
+//	pencil style -link https://example.com link "example"
+func runStyle(p pencil.Profile, args []string) error {
+	fs := flag.NewFlagSet("style", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	noNewline := fs.Bool("n", false, "do not print the trailing newline")
+	link := fs.String("link", "", "make the text a hyperlink to the URL")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		return errUsage
+	}
+	st, err := lookupStyle(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *link != "" {
+		st = st.LinkTo(*link, "")
+	}
+	text := fs.Args()[1:]
+	if len(text) > 0 && text[0] == "--" {
+		text = text[1:]
+	}
+
+	if len(text) > 0 {
+		s := st.SprintFor(p, strings.Join(text, " "))
+		if !*noNewline {
+			s += "\n"
+		}
+		_, err := io.WriteString(stdout, s)
+		return err
+	}
+	return eachLine(func(line string) string {
+		return st.SprintFor(p, line)
+	})
+}
+
+// paintRule styles the matches of re, or their lines
+type paintRule struct {
+	re    *regexp.Regexp
+	style *pencil.Style
+}
+
+// runPaint copies stdin to stdout with the matches of the rules styled:
+//
+//	tail -f app.log | pencil paint -match ERROR -style "white on red" -match WARN -style warning
+//
+// Each -style applies to the preceding -match; a -style before any -match
+// styles every line. The matches of the first rules win over the
+// overlapping matches of the following ones (see pencil.Highlighter).
+func runPaint(p pencil.Profile, args []string) error {
+	var rules []paintRule
+	var all *pencil.Style
+	fs := flag.NewFlagSet("paint", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Func("match", "regular expression", func(s string) error {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, paintRule{re: re})
+		return nil
+	})
+	fs.Func("style", "style of the preceding -match", func(s string) error {
+		st, err := lookupStyle(s)
+		if err != nil {
+			return err
+		}
+		switch {
+		case len(rules) == 0:
+			all = &st
+		case rules[len(rules)-1].style != nil:
+			return fmt.Errorf("two -style for -match %q", rules[len(rules)-1].re)
+		default:
+			rules[len(rules)-1].style = &st
+		}
+		return nil
+	})
+	wholeLine := fs.Bool("line", false, "style the whole lines of the matches")
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, "pencil paint:", err)
+		return errUsage
+	}
+	if fs.NArg() > 0 || len(rules) == 0 && all == nil {
+		return errUsage
+	}
+	h := pencil.NewHighlighter()
+	for _, r := range rules {
+		if r.style == nil {
+			return fmt.Errorf("missing -style for -match %q", r.re)
+		}
+		h.Rules = append(h.Rules, pencil.HighlightRule{Pattern: r.re, Style: *r.style})
+	}
+
+	return eachLine(func(line string) string {
+		if p.NoColor {
+			return line
+		}
+		if *wholeLine {
+			for _, r := range rules {
+				if r.re.MatchString(pencil.Strip(line)) {
+					return r.style.SprintFor(p, line)
+				}
+			}
+			if all != nil {
+				return all.SprintFor(p, line)
+			}
+			return line
+		}
+		if all != nil {
+			// the highlighter restores the style of the line after
+			// each match
+			line = all.SprintFor(p, line)
+		}
+		return h.HighlightFor(p, line)
+	})
+}
+
+// eachLine writes the lines of stdin converted by f to stdout; the output is
+// flushed whenever the input is idle, so it follows a stream such as tail -f
+func eachLine(f func(line string) string) error {
+	r := bufio.NewReader(stdin)
+	w := bufio.NewWriter(stdout)
+	for {
+		line, err := r.ReadString('\n')
+		if line != "" {
+			// the line ending is kept as it is
+			text := strings.TrimRight(line, "\r\n")
+			w.WriteString(f(text))
+			w.WriteString(line[len(text):])
+		}
+		if err == io.EOF {
+			return w.Flush()
+		}
+		if err != nil {
+			w.Flush()
+			return err
+		}
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
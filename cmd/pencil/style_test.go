@@ -0,0 +1,147 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/shyang107/pencil"
+)
+
+var ansi8 = pencil.Profile{Mode: pencil.ModeANSI8}
+
+// run runs the command f with args and the profile p on the input in,
+// returning its output
+func run(f func(pencil.Profile, []string) error, p pencil.Profile, in string, args ...string) (string, error) {
+	defer func(r io.Reader, w io.Writer) { stdin, stdout = r, w }(stdin, stdout)
+	var out strings.Builder
+	stdin, stdout = strings.NewReader(in), &out
+	err := f(p, args)
+	return out.String(), err
+}
+
+// testTheme sets for the test a theme whose "red" is blue
+func testTheme(t *testing.T) {
+	pencil.SetTheme(pencil.NewTheme("test").
+		Set("error", pencil.NewStyle(pencil.ANSIColor(1), pencil.Bold)).
+		Set("red", pencil.NewStyle(pencil.ANSIColor(4))))
+	t.Cleanup(func() { pencil.SetTheme(nil) })
+}
+
+func TestLookupStyle(t *testing.T) {
+	testTheme(t)
+	rgb := pencil.Profile{Mode: pencil.ModeRGB}
+	tests := []struct {
+		name string
+		p    pencil.Profile
+		want string
+	}{
+		{"error", ansi8, "\x1b[1;31mx\x1b[0m"},
+		{"log.level.error", ansi8, "\x1b[1;31mx\x1b[0m"},
+		// a name of the theme wins over the textual style
+		{"red", ansi8, "\x1b[34mx\x1b[0m"},
+		{"bold red", ansi8, "\x1b[1;31mx\x1b[0m"},
+		{"italic #ff8800 on 236", rgb, "\x1b[3;38;2;255;136;0;48;5;236mx\x1b[0m"},
+	}
+	for _, tt := range tests {
+		st, err := lookupStyle(tt.name)
+		if err != nil {
+			t.Errorf("lookupStyle(%q): %v", tt.name, err)
+			continue
+		}
+		if got := st.SprintFor(tt.p, "x"); got != tt.want {
+			t.Errorf("lookupStyle(%q) prints %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if _, err := lookupStyle("no-such-style"); err == nil {
+		t.Error("lookupStyle() of an unknown name succeeded")
+	}
+}
+
+func TestRunStyle(t *testing.T) {
+	testTheme(t)
+	tests := []struct {
+		p    pencil.Profile
+		in   string
+		args []string
+		want string
+	}{
+		{ansi8, "", []string{"error", "disk", "full"}, "\x1b[1;31mdisk full\x1b[0m\n"},
+		{ansi8, "", []string{"-n", "red", "--", "-x"}, "\x1b[34m-x\x1b[0m"},
+		{pencil.Profile{NoColor: true}, "", []string{"error", "x"}, "x\n"},
+		{pencil.Profile{NoColor: true}, "", []string{"-link", "https://example.com", "error", "docs"}, "docs (https://example.com)\n"},
+		{ansi8, "a\r\nb\n\nc", []string{"red"}, "\x1b[34ma\x1b[0m\r\n\x1b[34mb\x1b[0m\n\x1b[34m\x1b[0m\n\x1b[34mc\x1b[0m"},
+	}
+	for _, tt := range tests {
+		got, err := run(runStyle, tt.p, tt.in, tt.args...)
+		if err != nil || got != tt.want {
+			t.Errorf("style %q on %q = %q, %v, want %q", tt.args, tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, args := range [][]string{nil, {"-n"}, {"-x", "red"}} {
+		if _, err := run(runStyle, ansi8, "", args...); err != errUsage {
+			t.Errorf("style %q: %v, want errUsage", args, err)
+		}
+	}
+	if _, err := run(runStyle, ansi8, "", "no-such-style", "x"); err == nil {
+		t.Error("style of an unknown name succeeded")
+	}
+}
+
+func TestRunPaint(t *testing.T) {
+	testTheme(t)
+	in := "ok\r\nERROR here\nWARN there\n"
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{
+			[]string{"-match", "ERROR", "-style", "error", "-match", "WARN|there", "-style", "red"},
+			"ok\r\n\x1b[1;31mERROR\x1b[0m here\n\x1b[34mWARN\x1b[0m \x1b[34mthere\x1b[0m\n",
+		},
+		{
+			[]string{"-line", "-match", "ERROR", "-style", "error"},
+			"ok\r\n\x1b[1;31mERROR here\x1b[0m\nWARN there\n",
+		},
+		{
+			[]string{"-line", "-style", "red", "-match", "ERROR", "-style", "error"},
+			"\x1b[34mok\x1b[0m\r\n\x1b[1;31mERROR here\x1b[0m\n\x1b[34mWARN there\x1b[0m\n",
+		},
+		{
+			// a -style before any -match styles every line around the matches
+			[]string{"-style", "red", "-match", `\bhere\b`, "-style", "bold"},
+			"\x1b[34mok\x1b[0m\r\n\x1b[34mERROR \x1b[1mhere\x1b[0m\x1b[34m\x1b[0m\n\x1b[34mWARN there\x1b[0m\n",
+		},
+	}
+	for _, tt := range tests {
+		got, err := run(runPaint, ansi8, in, tt.args...)
+		if err != nil || got != tt.want {
+			t.Errorf("paint %q = %q, %v, want %q", tt.args, got, err, tt.want)
+		}
+	}
+
+	if got, err := run(runPaint, pencil.Profile{NoColor: true}, in, "-match", "ERROR", "-style", "error"); err != nil || got != in {
+		t.Errorf("paint without colors = %q, %v, want the input", got, err)
+	}
+
+	for _, tt := range []struct {
+		args  []string
+		usage bool
+	}{
+		{nil, true},
+		{[]string{"-match", "("}, true},
+		{[]string{"-match", "a", "-style", "red", "extra"}, true},
+		{[]string{"-match", "a", "-style", "red", "-style", "error"}, true},
+		{[]string{"-match", "a"}, false},
+		{[]string{"-match", "a", "-match", "b", "-style", "red"}, false},
+	} {
+		_, err := run(runPaint, ansi8, in, tt.args...)
+		switch {
+		case err == nil:
+			t.Errorf("paint %q succeeded", tt.args)
+		case tt.usage != (err == errUsage):
+			t.Errorf("paint %q: %v", tt.args, err)
+		}
+	}
+}
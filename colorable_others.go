@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package pencil
+
+import (
+	"io"
+	"os"
+)
+
+// NewColorable returns a writer on f which handles escape sequences; outside
+// of Windows terminals interpret them, so it is f itself.
+func NewColorable(f *os.File) io.Writer {
+	return f
+}
@@ -0,0 +1,57 @@
+//go:build windows
+// +build windows
+
+package pencil
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+var procSetConsoleTextAttribute = windows.NewLazySystemDLL("kernel32.dll").NewProc("SetConsoleTextAttribute")
+
+// winConsole implements Console on a Windows console handle
+type winConsole struct {
+	f *os.File
+}
+
+func (c *winConsole) Write(p []byte) (int, error) {
+	return c.f.Write(p)
+}
+
+func (c *winConsole) Attributes() (uint16, error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(c.f.Fd()), &info); err != nil {
+		return 0, err
+	}
+	return info.Attributes, nil
+}
+
+func (c *winConsole) SetAttributes(attr uint16) error {
+	r, _, err := procSetConsoleTextAttribute.Call(c.f.Fd(), uintptr(attr))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// NewColorable returns a writer on f which handles escape sequences: f
+// itself if it is not a console or the console interprets them (virtual
+// terminal processing is enabled if possible), otherwise a ConsoleWriter
+// translating the colors into console attributes.
+func NewColorable(f *os.File) io.Writer {
+	h := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return f // not a console
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return f
+	}
+	if err := windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err == nil {
+		return f
+	}
+	return NewConsoleWriter(&winConsole{f: f})
+}
@@ -0,0 +1,159 @@
+package pencil
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Console text attributes, as used by the Windows console API
+const (
+	ConsoleForegroundBlue      uint16 = 0x0001
+	ConsoleForegroundGreen     uint16 = 0x0002
+	ConsoleForegroundRed       uint16 = 0x0004
+	ConsoleForegroundIntensity uint16 = 0x0008
+	ConsoleBackgroundBlue      uint16 = 0x0010
+	ConsoleBackgroundGreen     uint16 = 0x0020
+	ConsoleBackgroundRed       uint16 = 0x0040
+	ConsoleBackgroundIntensity uint16 = 0x0080
+	ConsoleUnderscore          uint16 = 0x8000
+
+	consoleForegroundMask = ConsoleForegroundBlue | ConsoleForegroundGreen | ConsoleForegroundRed | ConsoleForegroundIntensity
+	consoleBackgroundMask = ConsoleBackgroundBlue | ConsoleBackgroundGreen | ConsoleBackgroundRed | ConsoleBackgroundIntensity
+)
+
+// Console is the attribute API of a console which cannot interpret escape
+// sequences, such as the legacy Windows console. Writes of text use the
+// attributes set last.
+type Console interface {
+	io.Writer
+	// Attributes returns the current text attributes (Console* bits)
+	Attributes() (uint16, error)
+	// SetAttributes sets the text attributes of subsequent writes
+	SetAttributes(attr uint16) error
+}
+
+// ConsoleWriter is an io.Writer emulating the SGR sequences written to it on
+// a Console: the text is written to the console and the colors and
+// attributes are translated into calls of SetAttributes. Colors are reduced
+// to the 16 colors of the console and other escape sequences are dropped.
+type ConsoleWriter struct {
+	escapeScanner
+	console     Console
+	defaultAttr uint16
+	attr        uint16
+	reverse     bool
+	mu          sync.Mutex // protects the state of the writer
+}
+
+// NewConsoleWriter returns a ConsoleWriter on the console c; the attributes
+// of c at this time are restored by a reset (SGR 0)
+func NewConsoleWriter(c Console) *ConsoleWriter {
+	attr, err := c.Attributes()
+	if err != nil {
+		attr = ConsoleForegroundRed | ConsoleForegroundGreen | ConsoleForegroundBlue
+	}
+	return &ConsoleWriter{console: c, defaultAttr: attr, attr: attr}
+}
+
+// Write implements io.Writer
+func (w *ConsoleWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.scan(p, func(text []byte) error {
+		_, err := w.console.Write(text)
+		return err
+	}, func(seq []byte) error {
+		if !isSGR(seq) {
+			return nil
+		}
+		prev := w.output()
+		w.attr = w.apply(string(seq[2 : len(seq)-1]))
+		if out := w.output(); out != prev {
+			return w.console.SetAttributes(out)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// ColorProfile implements Profiler: a console shows the basic colors only
+func (w *ConsoleWriter) ColorProfile() Profile {
+	return Profile{NoColor: NoColor, Mode: ModeANSI8}
+}
+
+// output returns the attributes to set on the console: w.attr with the
+// foreground and background swapped if reverse video is on
+func (w *ConsoleWriter) output() uint16 {
+	if !w.reverse {
+		return w.attr
+	}
+	fg := w.attr & consoleForegroundMask
+	bg := (w.attr & consoleBackgroundMask) >> 4
+	return w.attr&^(consoleForegroundMask|consoleBackgroundMask) | bg | fg<<4
+}
+
+// apply returns the attributes after the SGR parameters params
+func (w *ConsoleWriter) apply(params string) uint16 {
+	attr := w.attr
+	params = downgradeSGR(params, ModeANSI8)
+	if params == "" {
+		params = "0"
+	}
+	for _, f := range strings.Split(params, ";") {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 0:
+			attr, w.reverse = w.defaultAttr, false
+		case n == 1:
+			attr |= ConsoleForegroundIntensity
+		case n == 22:
+			attr &^= ConsoleForegroundIntensity
+		case n == 4:
+			attr |= ConsoleUnderscore
+		case n == 24:
+			attr &^= ConsoleUnderscore
+		case n == 7:
+			w.reverse = true
+		case n == 27:
+			w.reverse = false
+		case n >= 30 && n <= 37:
+			attr = attr&^(consoleForegroundMask&^ConsoleForegroundIntensity) | consoleColor(n-30)
+		case n >= 90 && n <= 97:
+			attr = attr&^consoleForegroundMask | consoleColor(n-90) | ConsoleForegroundIntensity
+		case n == 39:
+			attr = attr&^consoleForegroundMask | w.defaultAttr&consoleForegroundMask
+		case n >= 40 && n <= 47:
+			attr = attr&^(consoleBackgroundMask&^ConsoleBackgroundIntensity) | consoleColor(n-40)<<4
+		case n >= 100 && n <= 107:
+			attr = attr&^consoleBackgroundMask | consoleColor(n-100)<<4 | ConsoleBackgroundIntensity
+		case n == 49:
+			attr = attr&^consoleBackgroundMask | w.defaultAttr&consoleBackgroundMask
+		}
+	}
+	return attr
+}
+
+// consoleColor returns the foreground bits of the ANSI color index (0-7):
+// ANSI orders the bits red, green, blue and the console blue, green, red
+func consoleColor(index int) uint16 {
+	var attr uint16
+	if index&1 != 0 {
+		attr |= ConsoleForegroundRed
+	}
+	if index&2 != 0 {
+		attr |= ConsoleForegroundGreen
+	}
+	if index&4 != 0 {
+		attr |= ConsoleForegroundBlue
+	}
+	return attr
+}
@@ -0,0 +1,86 @@
+package pencil
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// fakeConsole is a Console recording the text written and the attributes
+// set, e.g. "red" and "attr=0x0004"
+type fakeConsole struct {
+	attr   uint16
+	err    error // returned by Attributes
+	events []string
+}
+
+func (c *fakeConsole) Write(p []byte) (int, error) {
+	c.events = append(c.events, string(p))
+	return len(p), nil
+}
+
+func (c *fakeConsole) Attributes() (uint16, error) {
+	return c.attr, c.err
+}
+
+func (c *fakeConsole) SetAttributes(attr uint16) error {
+	c.attr = attr
+	c.events = append(c.events, fmt.Sprintf("attr=%#04x", attr))
+	return nil
+}
+
+const consoleWhite = ConsoleForegroundRed | ConsoleForegroundGreen | ConsoleForegroundBlue
+
+func TestConsoleWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   []string
+	}{
+		{"color and reset", []string{"\x1b[31mred\x1b[0m plain"},
+			[]string{"attr=0x0004", "red", "attr=0x0007", " plain"}},
+		{"bold bright", []string{"\x1b[1;34mx"},
+			[]string{"attr=0x0009", "x"}},
+		{"bright foreground", []string{"\x1b[92mx\x1b[39my"},
+			[]string{"attr=0x000a", "x", "attr=0x0007", "y"}},
+		{"background and underline", []string{"\x1b[4;42mx\x1b[24;49my"},
+			[]string{"attr=0x8027", "x", "attr=0x0007", "y"}},
+		{"bright background", []string{"\x1b[101mx"},
+			[]string{"attr=0x00c7", "x"}},
+		{"reverse video", []string{"\x1b[31;44;7mx\x1b[27my"},
+			[]string{"attr=0x0041", "x", "attr=0x0014", "y"}},
+		{"256 colors downgraded", []string{"\x1b[38;5;196mx"},
+			[]string{"attr=0x000c", "x"}},
+		{"unchanged attributes", []string{"\x1b[0mx\x1b[39;49my"},
+			[]string{"x", "y"}},
+		{"other sequences dropped", []string{"\x1b[2Kx\x1b]0;title\ay"},
+			[]string{"x", "y"}},
+		{"sequence split across writes", []string{"a\x1b[3", "1mb"},
+			[]string{"a", "attr=0x0004", "b"}},
+	}
+	for _, tt := range tests {
+		c := &fakeConsole{attr: consoleWhite}
+		w := NewConsoleWriter(c)
+		for _, s := range tt.writes {
+			if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+				t.Errorf("%s: Write(%q) = %d, %v", tt.name, s, n, err)
+			}
+		}
+		if !reflect.DeepEqual(c.events, tt.want) {
+			t.Errorf("%s: console events = %q, want %q", tt.name, c.events, tt.want)
+		}
+	}
+}
+
+func TestConsoleWriterAttributesError(t *testing.T) {
+	// the default attributes are white on black if the console does not
+	// report its attributes
+	c := &fakeConsole{attr: ConsoleForegroundRed, err: errors.New("no console")}
+	w := NewConsoleWriter(c)
+	fmt.Fprint(w, "\x1b[32mx\x1b[0m")
+	want := []string{"attr=0x0002", "x", "attr=0x0007"}
+	if !reflect.DeepEqual(c.events, want) {
+		t.Errorf("console events = %q, want %q", c.events, want)
+	}
+}
@@ -0,0 +1,237 @@
+// Package diff computes line diffs and renders unified diffs in color:
+//
+//	fmt.Print(diff.Strings("old.yaml", "new.yaml", oldText, newText, nil))
+//
+// Render colors an existing unified diff, e.g. the output of "git diff". In
+// both cases a removed line followed by an added one is compared word by
+// word, and the changed words are highlighted with a background color.
+//
+// The styles are taken from a pencil.Theme by the names diff.added,
+// diff.removed, diff.added.highlight, diff.removed.highlight, diff.context,
+// diff.hunk and diff.header, which the package adds to pencil.DefaultTheme;
+// in other themes they fall back to "added", "removed" ... (see
+// pencil.Theme.Lookup).
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is the operation of an Edit
+type Op int
+
+// Edit operations
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+// String returns the prefix of the operation in a unified diff: " ", "+"
+// or "-"
+func (op Op) String() string {
+	switch op {
+	case Insert:
+		return "+"
+	case Delete:
+		return "-"
+	default:
+		return " "
+	}
+}
+
+// Edit is an element of a diff: Text is kept, inserted or deleted
+type Edit struct {
+	Op   Op
+	Text string
+}
+
+// Diff returns the shortest list of edits turning a into b, computed by
+// Myers' O(ND) algorithm
+func Diff(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	maxD := n + m
+	if maxD == 0 {
+		return nil
+	}
+	off := maxD + 1
+	v := make([]int, 2*maxD+2)
+	// trace[d] is v[-d:d+1] before the round d, the only diagonals the
+	// round reads, so the trace takes O(D²) space instead of O(D·(N+M))
+	var trace [][]int
+
+search:
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[off+k-1] < v[off+k+1] {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// backtrack from (n, m), collecting the edits in reverse order
+	edits := make([]Edit, 0, maxD)
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevX, prevY := 0, 0 // the round 0 starts at (0, 0)
+		if d > 0 {
+			var prevK int
+			if k == -d || k != d && v[d+k-1] < v[d+k+1] {
+				prevK = k + 1
+			} else {
+				prevK = k - 1
+			}
+			prevX = v[d+prevK]
+			prevY = prevX - prevK
+		}
+		for x > prevX && y > prevY {
+			edits = append(edits, Edit{Equal, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, Edit{Insert, b[y-1]})
+			} else {
+				edits = append(edits, Edit{Delete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// Lines splits s into lines without their newlines; a final newline does not
+// start another line
+func Lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// noNewline marks the last line of a text without a final newline, in the
+// form of the unified diffs; the line differs from the same line ending with
+// a newline
+const noNewline = "\n\\ No newline at end of file"
+
+// textLines is Lines with noNewline appended to a last line without newline
+func textLines(s string) []string {
+	lines := Lines(s)
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		lines[len(lines)-1] += noNewline
+	}
+	return lines
+}
+
+// Unified returns the unified diff of the texts a and b, named aName and
+// bName in the header, with context lines around each change; it is empty
+// if the texts are equal. A missing newline at the end of a text is shown
+// as by diff -u, by the line "\ No newline at end of file".
+func Unified(aName, bName, a, b string, context int) string {
+	edits := Diff(textLines(a), textLines(b))
+	var out strings.Builder
+	for _, h := range hunks(edits, context) {
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(h.aStart, h.aLen), hunkRange(h.bStart, h.bLen))
+		for _, e := range h.edits {
+			out.WriteString(e.Op.String())
+			out.WriteString(e.Text)
+			out.WriteByte('\n')
+		}
+	}
+	return out.String()
+}
+
+// hunk is a group of edits with their context
+type hunk struct {
+	aStart, aLen int // 0-based first line and count of lines in a
+	bStart, bLen int
+	edits        []Edit
+}
+
+// hunks groups the changes of edits with context lines around them; changes
+// separated by at most 2*context lines share a hunk
+func hunks(edits []Edit, context int) []hunk {
+	if context < 0 {
+		context = 0
+	}
+	// the line numbers in a and b before each edit
+	aLine := make([]int, len(edits)+1)
+	bLine := make([]int, len(edits)+1)
+	for i, e := range edits {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if e.Op != Insert {
+			aLine[i+1]++
+		}
+		if e.Op != Delete {
+			bLine[i+1]++
+		}
+	}
+
+	var hs []hunk
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			i++
+			continue
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		// extend the hunk while the next change is close enough
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].Op != Equal {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		stop := end + context
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+		hs = append(hs, hunk{
+			aStart: aLine[start], aLen: aLine[stop] - aLine[start],
+			bStart: bLine[start], bLen: bLine[stop] - bLine[start],
+			edits: edits[start:stop],
+		})
+		i = stop
+	}
+	return hs
+}
+
+// hunkRange returns the range of a hunk header, e.g. "3,4"; the line is
+// 1-based and the count is omitted if it is 1, as GNU diff does
+func hunkRange(start, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+}
@@ -0,0 +1,216 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shyang107/pencil"
+)
+
+func TestUnifiedNoNewline(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"x", "x", ""},
+		{"x", "x\n", "@@ -1 +1 @@\n-x\n\\ No newline at end of file\n+x\n"},
+		{"x\n", "x", "@@ -1 +1 @@\n-x\n+x\n\\ No newline at end of file\n"},
+		{"a\nx", "b\nx", "@@ -1,2 +1,2 @@\n-a\n+b\n x\n\\ No newline at end of file\n"},
+		{"a\nx", "a\ny", "@@ -1,2 +1,2 @@\n a\n-x\n\\ No newline at end of file\n+y\n\\ No newline at end of file\n"},
+	}
+	for _, tt := range tests {
+		want := tt.want
+		if want != "" {
+			want = "--- a\n+++ b\n" + want
+		}
+		if got := Unified("a", "b", tt.a, tt.b, 3); got != want {
+			t.Errorf("Unified(%q, %q) = %q, want %q", tt.a, tt.b, got, want)
+		}
+	}
+}
+
+func TestRenderNoNewline(t *testing.T) {
+	// the marker is kept plain and the changed lines around it are paired
+	p := pencil.Profile{Mode: pencil.ModeANSI8}
+	out := Strings("a", "b", "one two", "one three\n", &Options{Profile: &p})
+	if !strings.Contains(out, "\n\\ No newline at end of file\n") {
+		t.Errorf("Strings() = %q, want the marker on a plain line", out)
+	}
+	hi := pencil.DefaultTheme.Style("diff.added.highlight").SprintFor(p, "three")
+	if !strings.Contains(out, hi) {
+		t.Errorf("Strings() = %q, want %q highlighted", out, "three")
+	}
+}
+
+// lcs returns the length of the longest common subsequence of a and b
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestDiffMinimal(t *testing.T) {
+	// every pair of texts over a small alphabet up to 5 lines: the edits
+	// turn a into b and keep a longest common subsequence
+	var texts [][]string
+	var gen func(prefix []string)
+	gen = func(prefix []string) {
+		texts = append(texts, prefix)
+		if len(prefix) == 5 {
+			return
+		}
+		for _, s := range []string{"a", "b", "c"} {
+			gen(append(append([]string(nil), prefix...), s))
+		}
+	}
+	gen(nil)
+	for _, a := range texts {
+		for _, b := range texts {
+			var gotA, gotB []string
+			equal := 0
+			for _, e := range Diff(a, b) {
+				if e.Op != Insert {
+					gotA = append(gotA, e.Text)
+				}
+				if e.Op != Delete {
+					gotB = append(gotB, e.Text)
+				}
+				if e.Op == Equal {
+					equal++
+				}
+			}
+			if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+				t.Fatalf("Diff(%q, %q) does not turn a into b", a, b)
+			}
+			if want := lcs(a, b); equal != want {
+				t.Fatalf("Diff(%q, %q) keeps %d lines, want %d", a, b, equal, want)
+			}
+		}
+	}
+}
+
+func TestHunks(t *testing.T) {
+	// changes on the lines 2 and 2+gap+1 of 12 lines, with 2 lines of
+	// context: a gap of up to 4 lines shares a hunk
+	text := func(changed ...int) string {
+		var b strings.Builder
+		for i := 1; i <= 12; i++ {
+			s := fmt.Sprint(i)
+			for _, c := range changed {
+				if c == i {
+					s += "x"
+				}
+			}
+			b.WriteString(s + "\n")
+		}
+		return b.String()
+	}
+	tests := []struct {
+		gap   int
+		hunks []string
+	}{
+		{3, []string{"@@ -1,8 +1,8 @@"}},
+		{4, []string{"@@ -1,9 +1,9 @@"}},
+		{5, []string{"@@ -1,4 +1,4 @@", "@@ -6,5 +6,5 @@"}},
+	}
+	for _, tt := range tests {
+		out := Unified("a", "b", text(), text(2, 2+tt.gap+1), 2)
+		var got []string
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasPrefix(line, "@@") {
+				got = append(got, line)
+			}
+		}
+		if strings.Join(got, "|") != strings.Join(tt.hunks, "|") {
+			t.Errorf("gap %d: hunks %q, want %q", tt.gap, got, tt.hunks)
+		}
+	}
+
+	if out := Unified("a", "b", "1\n2\n", "1\n2\n3\n", 0); !strings.Contains(out, "@@ -2,0 +3 @@\n+3\n") {
+		t.Errorf("Unified() without context = %q", out)
+	}
+}
+
+func TestHunkRange(t *testing.T) {
+	tests := []struct {
+		start, n int
+		want     string
+	}{
+		{0, 0, "0,0"},
+		{4, 0, "4,0"},
+		{0, 1, "1"},
+		{4, 1, "5"},
+		{4, 3, "5,3"},
+	}
+	for _, tt := range tests {
+		if got := hunkRange(tt.start, tt.n); got != tt.want {
+			t.Errorf("hunkRange(%d, %d) = %q, want %q", tt.start, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestRenderGitDiff(t *testing.T) {
+	in := `diff --git a/main.go b/main.go
+index 3b18e51..a2c4f0d 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,4 @@
+ package main
+ 
+-func hello() string { return "hello" }
++func hello() string { return "hi" }
+ // end
+`
+	p := pencil.Profile{Mode: pencil.ModeANSI8}
+	th := pencil.DefaultTheme
+	out := Render(in, &Options{Profile: &p, Theme: th})
+	style := func(name, s string) string { return th.Style(name).SprintFor(p, s) }
+	for _, want := range []string{
+		style("diff.header", "diff --git a/main.go b/main.go") + "\n",
+		style("diff.header", "index 3b18e51..a2c4f0d 100644") + "\n",
+		style("diff.header", "--- a/main.go") + "\n",
+		style("diff.header", "+++ b/main.go") + "\n",
+		style("diff.hunk", "@@ -1,4 +1,4 @@") + "\n",
+		style("diff.context", " package main") + "\n",
+		style("diff.removed.highlight", "hello"),
+		style("diff.added.highlight", "hi"),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Render() = %q, want %q", out, want)
+		}
+	}
+	if got := pencil.Strip(out); got != in {
+		t.Errorf("Render() without the colors = %q, want %q", got, in)
+	}
+
+	p.NoColor = true
+	if got := Render(in, &Options{Profile: &p}); got != in {
+		t.Errorf("Render() with NoColor = %q, want the input", got)
+	}
+}
+
+func TestDefaultThemeNames(t *testing.T) {
+	// only the diff.* names are added, the generic ones are left to themes
+	for _, name := range []string{"added", "removed", "context", "hunk", "header"} {
+		if _, ok := pencil.DefaultTheme.Styles[name]; ok {
+			t.Errorf("DefaultTheme defines %q", name)
+		}
+		if _, ok := pencil.DefaultTheme.Styles["diff."+name]; !ok {
+			t.Errorf("DefaultTheme does not define %q", "diff."+name)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package diff
+
+import (
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/shyang107/pencil"
+)
+
+// DefaultContext is the number of context lines around the changes unless set
+// by Options
+const DefaultContext = 3
+
+func init() {
+	pencil.DefaultTheme.
+		Set("diff.added", pencil.NewStyle(pencil.ANSIColor(2))).
+		Set("diff.removed", pencil.NewStyle(pencil.ANSIColor(1))).
+		Set("diff.added.highlight", pencil.NewStyle(pencil.ANSIColor(15)).On(pencil.ANSIColor(2))).
+		Set("diff.removed.highlight", pencil.NewStyle(pencil.ANSIColor(15)).On(pencil.ANSIColor(1))).
+		Set("diff.context", pencil.Style{}).
+		Set("diff.hunk", pencil.NewStyle(pencil.ANSIColor(6))).
+		Set("diff.header", pencil.NewStyle(nil, pencil.Bold))
+}
+
+// Options are the options of the rendering; nil means the defaults
+type Options struct {
+	// Context is the number of context lines around the changes; 0 means
+	// DefaultContext and a negative number none
+	Context int
+	// Theme gives the styles; pencil.CurrentTheme() if nil
+	Theme *pencil.Theme
+	// Profile is the color profile of the output; the global pencil.NoColor
+	// and the color mode of the terminal if nil
+	Profile *pencil.Profile
+	// NoWords disables the highlighting of the changed words
+	NoWords bool
+}
+
+// Strings returns the colored unified diff of the texts a and b, named aName
+// and bName in the header; it is empty if the texts are equal
+func Strings(aName, bName, a, b string, opts *Options) string {
+	return Render(Unified(aName, bName, a, b, opts.context()), opts)
+}
+
+// Fprint writes the colored unified diff of the texts a and b to w, in the
+// color profile of w (see pencil.NewWriter) unless set by opts
+func Fprint(w io.Writer, aName, bName, a, b string, opts *Options) error {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Profile == nil {
+		p := pencil.NewWriter(w).Profile
+		o.Profile = &p
+	}
+	_, err := io.WriteString(w, Strings(aName, bName, a, b, &o))
+	return err
+}
+
+// Render colors the unified diff text, e.g. the output of "git diff"
+func Render(text string, opts *Options) string {
+	r := newRenderer(opts)
+	var out strings.Builder
+	lines := strings.SplitAfter(text, "\n")
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if !strings.HasPrefix(line, "-") || strings.HasPrefix(line, "--- ") {
+			out.WriteString(r.line(line))
+			i++
+			continue
+		}
+		// a block of removed lines, maybe followed by the added ones
+		j := i
+		for j < len(lines) && (isChange(lines[j], '-') || isNoNewline(lines[j])) {
+			j++
+		}
+		k := j
+		for k < len(lines) && (isChange(lines[k], '+') || isNoNewline(lines[k])) {
+			k++
+		}
+		r.block(&out, lines[i:j], lines[j:k])
+		i = k
+	}
+	return out.String()
+}
+
+// isNoNewline reports whether line is the "\ No newline at end of file"
+// marker following the last line of a text
+func isNoNewline(line string) bool {
+	return strings.HasPrefix(line, `\`)
+}
+
+// isChange reports whether line is an added (op '+') or removed (op '-')
+// line, not a file header
+func isChange(line string, op byte) bool {
+	return len(line) > 0 && line[0] == op &&
+		!strings.HasPrefix(line, "--- ") && !strings.HasPrefix(line, "+++ ")
+}
+
+type renderer struct {
+	theme   *pencil.Theme
+	profile pencil.Profile
+	words   bool
+}
+
+func newRenderer(opts *Options) *renderer {
+	r := &renderer{
+		theme:   pencil.CurrentTheme(),
+		profile: pencil.Profile{NoColor: pencil.NoColor, Mode: pencil.ColorProfile.Mode},
+		words:   true,
+	}
+	if opts != nil {
+		if opts.Theme != nil {
+			r.theme = opts.Theme
+		}
+		if opts.Profile != nil {
+			r.profile = *opts.Profile
+		}
+		r.words = !opts.NoWords
+	}
+	return r
+}
+
+func (o *Options) context() int {
+	switch {
+	case o == nil || o.Context == 0:
+		return DefaultContext
+	case o.Context < 0:
+		return 0
+	default:
+		return o.Context
+	}
+}
+
+// style returns s in the style name; the newline of s is kept out of the
+// colors
+func (r *renderer) style(name, s string) string {
+	text := strings.TrimSuffix(s, "\n")
+	return r.theme.Style(name).SprintFor(r.profile, text) + s[len(text):]
+}
+
+// line returns the colored line of a unified diff
+func (r *renderer) line(line string) string {
+	switch {
+	case strings.HasPrefix(line, "@@"):
+		return r.style("diff.hunk", line)
+	case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "),
+		strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "index "):
+		return r.style("diff.header", line)
+	case strings.HasPrefix(line, "+"):
+		return r.style("diff.added", line)
+	case strings.HasPrefix(line, "-"):
+		return r.style("diff.removed", line)
+	case strings.HasPrefix(line, " "):
+		return r.style("diff.context", line)
+	default:
+		return line
+	}
+}
+
+// block writes the removed lines and the added lines following them; the
+// lines are paired in order and the words changed within a pair highlighted
+func (r *renderer) block(out *strings.Builder, removed, added []string) {
+	hiRemoved := make([]string, len(removed))
+	hiAdded := make([]string, len(added))
+	if r.words && !r.profile.NoColor {
+		ri, ai := changes(removed), changes(added)
+		for k := 0; k < len(ri) && k < len(ai); k++ {
+			hiRemoved[ri[k]], hiAdded[ai[k]] = r.highlight(removed[ri[k]], added[ai[k]])
+		}
+	}
+	for i, line := range removed {
+		if hiRemoved[i] != "" {
+			out.WriteString(hiRemoved[i])
+		} else {
+			out.WriteString(r.line(line))
+		}
+	}
+	for i, line := range added {
+		if hiAdded[i] != "" {
+			out.WriteString(hiAdded[i])
+		} else {
+			out.WriteString(r.line(line))
+		}
+	}
+}
+
+// changes returns the indexes of the changed lines of a block, i.e. without
+// the "no newline" markers
+func changes(lines []string) []int {
+	var idx []int
+	for i, line := range lines {
+		if !isNoNewline(line) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// highlight returns the removed line a and the added line b with the changed
+// words highlighted; both are empty if the lines have too little in common
+// for the highlighting to help
+func (r *renderer) highlight(a, b string) (string, string) {
+	at := strings.TrimSuffix(a[1:], "\n")
+	bt := strings.TrimSuffix(b[1:], "\n")
+	edits := Diff(words(at), words(bt))
+
+	common := 0
+	for _, e := range edits {
+		if e.Op == Equal {
+			common += len(e.Text)
+		}
+	}
+	if 2*common < len(at) && 2*common < len(bt) {
+		return "", ""
+	}
+
+	ra := runs{r: r, name: "diff.removed", text: "-"}
+	rb := runs{r: r, name: "diff.added", text: "+"}
+	for _, e := range edits {
+		switch e.Op {
+		case Equal:
+			ra.add("diff.removed", e.Text)
+			rb.add("diff.added", e.Text)
+		case Delete:
+			ra.add("diff.removed.highlight", e.Text)
+		case Insert:
+			rb.add("diff.added.highlight", e.Text)
+		}
+	}
+	return ra.String() + a[1+len(at):], rb.String() + b[1+len(bt):]
+}
+
+// runs builds a line from texts in styles, joining the adjacent texts of
+// the same style into a single colored run
+type runs struct {
+	r    *renderer
+	out  strings.Builder
+	name string // the style of text
+	text string // the pending text
+}
+
+func (s *runs) add(name, text string) {
+	if name != s.name {
+		s.out.WriteString(s.r.style(s.name, s.text))
+		s.name, s.text = name, ""
+	}
+	s.text += text
+}
+
+func (s *runs) String() string {
+	return s.out.String() + s.r.style(s.name, s.text)
+}
+
+// words splits s into words, runs of spaces and single punctuation
+// characters, which are the units of the highlighting of the changes
+func words(s string) []string {
+	var ws []string
+	start := 0
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 0
+		case unicode.IsSpace(r):
+			return 1
+		default:
+			return 2
+		}
+	}
+	prev := -1
+	for i, c := range s {
+		cl := class(c)
+		if i > start && (cl != prev || cl == 2) {
+			ws = append(ws, s[start:i])
+			start = i
+		}
+		prev = cl
+	}
+	if start < len(s) {
+		ws = append(ws, s[start:])
+	}
+	return ws
+}
@@ -0,0 +1,178 @@
+// Package dither converts the colors of images to the colors of a palette,
+// such as ansirgb.Palette, ansirgb.Basic or pencil.PalettePlan9, spreading
+// the error of the conversion so that gradients and photos keep their tones:
+//
+//	dither.Draw(img, ansirgb.CurrentBasic(), dither.FloydSteinberg)
+//	p, err := dither.Paletted(img, pencil.PalettePlan9, dither.BlueNoise)
+package dither
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+// Method is a method of dithering
+type Method int
+
+// Methods of dithering
+const (
+	// None converts each color to the closest one of the palette
+	None Method = iota
+	// Bayer adds the thresholds of an 8x8 Bayer matrix (ordered dithering)
+	Bayer
+	// FloydSteinberg diffuses the whole error to four neighbors
+	FloydSteinberg
+	// Atkinson diffuses three quarters of the error to six neighbors, which
+	// keeps more contrast
+	Atkinson
+	// BlueNoise adds the thresholds of a blue noise texture, without the
+	// cross-hatch pattern of Bayer
+	BlueNoise
+)
+
+var methodNames = []string{"none", "bayer", "floyd-steinberg", "atkinson", "blue-noise"}
+
+// String returns the name of the method, e.g. "floyd-steinberg"
+func (m Method) String() string {
+	if m >= 0 && int(m) < len(methodNames) {
+		return methodNames[m]
+	}
+	return fmt.Sprintf("Method(%d)", int(m))
+}
+
+// ParseMethod returns the method of name, as returned by String
+func ParseMethod(name string) (Method, error) {
+	for i, n := range methodNames {
+		if strings.EqualFold(name, n) {
+			return Method(i), nil
+		}
+	}
+	return None, fmt.Errorf("dither: unknown method %q", name)
+}
+
+// Draw replaces the colors of img by colors of pal with the method m; the
+// pixels more than half transparent are left unchanged
+func Draw(img draw.Image, pal color.Palette, m Method) {
+	quantize(img.Bounds(), pal, m, func(x, y int) (color.NRGBA, bool) {
+		c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+		return c, c.A >= 0x80
+	}, func(x, y, i int) {
+		img.Set(x, y, pal[i])
+	})
+}
+
+// ErrPaletteSize is returned by Paletted for a palette which an
+// image.Paletted cannot index, with no color or more than 256
+var ErrPaletteSize = errors.New("dither: a paletted image has 1 to 256 colors")
+
+// Paletted returns img converted to pal with the method m; the pixels more
+// than half transparent take the closest color of pal without dithering.
+// ErrPaletteSize is returned if pal is empty or has more than 256 colors,
+// e.g. ansirgb.Palette with a scheme set.
+func Paletted(img image.Image, pal color.Palette, m Method) (*image.Paletted, error) {
+	if len(pal) == 0 || len(pal) > 256 {
+		return nil, ErrPaletteSize
+	}
+	b := img.Bounds()
+	p := image.NewPaletted(b, pal)
+	quantize(b, pal, m, func(x, y int) (color.NRGBA, bool) {
+		c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+		if c.A < 0x80 {
+			p.SetColorIndex(x, y, uint8(pal.Index(img.At(x, y))))
+			return c, false
+		}
+		return c, true
+	}, func(x, y, i int) {
+		p.SetColorIndex(x, y, uint8(i))
+	})
+	return p, nil
+}
+
+// weight is a share of the error diffused to the neighbor (dx, dy)
+type weight struct {
+	dx, dy int
+	w      float64
+}
+
+var (
+	floydSteinberg = []weight{{1, 0, 7.0 / 16}, {-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16}}
+	atkinson       = []weight{{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8}, {-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8}, {0, 2, 1.0 / 8}}
+)
+
+// quantize calls set with the index in pal of each pixel of b returned by
+// at, in row order; the pixels for which at returns false are skipped
+func quantize(b image.Rectangle, pal color.Palette, m Method, at func(x, y int) (color.NRGBA, bool), set func(x, y, i int)) {
+	if len(pal) == 0 || b.Empty() {
+		return
+	}
+	var kernel []weight
+	var thresholds *matrix
+	switch m {
+	case FloydSteinberg:
+		kernel = floydSteinberg
+	case Atkinson:
+		kernel = atkinson
+	case Bayer:
+		thresholds = bayerMatrix()
+	case BlueNoise:
+		thresholds = blueNoiseMatrix()
+	}
+	// the spread of the thresholds is about the distance of the colors of
+	// the palette
+	spread := 256 / math.Cbrt(float64(len(pal)))
+
+	w := b.Dx()
+	var errs [][3]float64
+	if kernel != nil {
+		errs = make([][3]float64, w*b.Dy())
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c, ok := at(x, y)
+			if !ok {
+				continue
+			}
+			v := [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+			switch {
+			case thresholds != nil:
+				t := thresholds.at(x-b.Min.X, y-b.Min.Y) * spread
+				v[0], v[1], v[2] = v[0]+t, v[1]+t, v[2]+t
+			case kernel != nil:
+				e := errs[(y-b.Min.Y)*w+x-b.Min.X]
+				v[0], v[1], v[2] = v[0]+e[0], v[1]+e[1], v[2]+e[2]
+			}
+			i := pal.Index(color.NRGBA{clamp(v[0]), clamp(v[1]), clamp(v[2]), 0xff})
+			set(x, y, i)
+			if kernel == nil {
+				continue
+			}
+			qr, qg, qb, _ := pal[i].RGBA()
+			q := [3]float64{float64(qr >> 8), float64(qg >> 8), float64(qb >> 8)}
+			for _, k := range kernel {
+				nx, ny := x-b.Min.X+k.dx, y-b.Min.Y+k.dy
+				if nx < 0 || nx >= w || ny >= b.Dy() {
+					continue
+				}
+				e := &errs[ny*w+nx]
+				for j := range e {
+					e[j] += (v[j] - q[j]) * k.w
+				}
+			}
+		}
+	}
+}
+
+func clamp(v float64) uint8 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 255:
+		return 255
+	}
+	return uint8(v + 0.5)
+}
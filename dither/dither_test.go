@@ -0,0 +1,33 @@
+package dither
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPalettedSize(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	gray := func(n int) color.Palette {
+		p := make(color.Palette, n)
+		for i := range p {
+			p[i] = color.Gray{uint8(i * 255 / 256)}
+		}
+		return p
+	}
+	for _, n := range []int{0, 257} {
+		if _, err := Paletted(img, gray(n), FloydSteinberg); err != ErrPaletteSize {
+			t.Errorf("Paletted() with %d colors: error = %v, want ErrPaletteSize", n, err)
+		}
+	}
+	p, err := Paletted(img, gray(256), FloydSteinberg)
+	if err != nil {
+		t.Fatalf("Paletted() with 256 colors: error = %v", err)
+	}
+	if i := p.ColorIndexAt(0, 0); i != 255 {
+		t.Errorf("index of white = %d, want 255", i)
+	}
+}
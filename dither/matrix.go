@@ -0,0 +1,143 @@
+package dither
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// matrix is a square matrix of thresholds in [-0.5, 0.5), tiled over the
+// image
+type matrix struct {
+	size int
+	t    []float64
+}
+
+func (m *matrix) at(x, y int) float64 {
+	return m.t[(y%m.size)*m.size+x%m.size]
+}
+
+// newMatrix returns the matrix of the ranks 0 to size*size-1
+func newMatrix(size int, ranks []int) *matrix {
+	m := &matrix{size: size, t: make([]float64, len(ranks))}
+	for i, r := range ranks {
+		m.t[i] = (float64(r)+0.5)/float64(len(ranks)) - 0.5
+	}
+	return m
+}
+
+var (
+	bayerOnce, blueNoiseOnce sync.Once
+	bayer, blueNoise         *matrix
+)
+
+// bayerMatrix returns the 8x8 Bayer matrix
+func bayerMatrix() *matrix {
+	bayerOnce.Do(func() {
+		// each matrix of size 2n is made of four copies of the one of size n
+		ranks, size := []int{0}, 1
+		for size < 8 {
+			next := make([]int, 4*size*size)
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					r := 4 * ranks[y*size+x]
+					next[y*2*size+x] = r
+					next[y*2*size+x+size] = r + 2
+					next[(y+size)*2*size+x] = r + 3
+					next[(y+size)*2*size+x+size] = r + 1
+				}
+			}
+			ranks, size = next, 2*size
+		}
+		bayer = newMatrix(size, ranks)
+	})
+	return bayer
+}
+
+// blueNoiseSize is the size of the blue noise texture
+const blueNoiseSize = 32
+
+// blueNoiseMatrix returns a blue noise texture made by the void-and-cluster
+// method of Ulichney: the pixels are ranked by adding them one by one where
+// they are the farthest from the others.
+func blueNoiseMatrix() *matrix {
+	blueNoiseOnce.Do(func() {
+		const size = blueNoiseSize
+		const n = size * size
+
+		// the gaussian weight of the distance of two pixels, on a torus
+		var gauss [size][size]float64
+		for dy := 0; dy < size; dy++ {
+			for dx := 0; dx < size; dx++ {
+				x, y := math.Min(float64(dx), float64(size-dx)), math.Min(float64(dy), float64(size-dy))
+				gauss[dy][dx] = math.Exp(-(x*x + y*y) / (2 * 1.5 * 1.5))
+			}
+		}
+		// energy is the sum of the weights of the set pixels at each pixel
+		type state struct {
+			set    [n]bool
+			energy [n]float64
+		}
+		toggle := func(s *state, i int) {
+			s.set[i] = !s.set[i]
+			sign := 1.0
+			if !s.set[i] {
+				sign = -1
+			}
+			ix, iy := i%size, i/size
+			for j := range s.energy {
+				dx, dy := (j%size-ix+size)%size, (j/size-iy+size)%size
+				s.energy[j] += sign * gauss[dy][dx]
+			}
+		}
+		// extreme returns the set pixel of the highest energy, the tightest
+		// cluster, or the unset one of the lowest, the largest void
+		extreme := func(s *state, set bool) int {
+			best := -1
+			for i := range s.energy {
+				if s.set[i] != set {
+					continue
+				}
+				if best < 0 || set && s.energy[i] > s.energy[best] || !set && s.energy[i] < s.energy[best] {
+					best = i
+				}
+			}
+			return best
+		}
+
+		// a random initial pattern of a tenth of the pixels, spread evenly by
+		// moving the pixels of the tightest cluster to the largest void
+		var initial state
+		rnd := rand.New(rand.NewSource(1))
+		ones := n / 10
+		for _, i := range rnd.Perm(n)[:ones] {
+			toggle(&initial, i)
+		}
+		for k := 0; k < n; k++ {
+			c := extreme(&initial, true)
+			toggle(&initial, c)
+			v := extreme(&initial, false)
+			if v == c {
+				toggle(&initial, c)
+				break
+			}
+			toggle(&initial, v)
+		}
+
+		ranks := make([]int, n)
+		s := initial
+		for r := ones - 1; r >= 0; r-- {
+			c := extreme(&s, true)
+			toggle(&s, c)
+			ranks[c] = r
+		}
+		s = initial
+		for r := ones; r < n; r++ {
+			v := extreme(&s, false)
+			toggle(&s, v)
+			ranks[v] = r
+		}
+		blueNoise = newMatrix(size, ranks)
+	})
+	return blueNoise
+}
@@ -0,0 +1,332 @@
+package pencil
+
+import (
+	"fmt"
+	"image/color"
+	"regexp"
+	"strings"
+
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+// RTFOptions are the options of the conversion to RTF
+type RTFOptions struct {
+	// Font is the name of the monospace font; "Courier New" if empty
+	Font string
+	// FontSize is the size of the font in points; 10 if 0
+	FontSize int
+	// Scheme gives the colors 0-15 and the default colors of reverse
+	// video; the current scheme of ansirgb if nil
+	Scheme *ansirgb.Scheme
+}
+
+// ToRTF converts the SGR sequences of s, such as the ones written by
+// pencil, into an RTF document with a color table, for word processors; the
+// other escape sequences are dropped.
+func ToRTF(s string) string {
+	return RTFOptions{}.ToRTF(s)
+}
+
+// ToRTF is ToRTF with the options o
+func (o RTFOptions) ToRTF(s string) string {
+	if o.Font == "" {
+		o.Font = "Courier New"
+	}
+	if o.FontSize == 0 {
+		o.FontSize = 10
+	}
+	if o.Scheme == nil {
+		o.Scheme, _ = ansirgb.CurrentScheme()
+	}
+	fgDefault, bgDefault := schemeDefaults(o.Scheme)
+
+	// the colors, in the order of the table; 0 is the automatic color
+	var colors []color.Color
+	index := map[string]int{}
+	colorIndex := func(c color.Color) int {
+		key := cssColor(c)
+		if i, ok := index[key]; ok {
+			return i
+		}
+		colors = append(colors, c)
+		index[key] = len(colors)
+		return len(colors)
+	}
+	rgb := func(spec *ColorSpec) color.Color {
+		if spec.Mode != ModeRGB && spec.Code >= 0 && spec.Code < 16 {
+			return o.Scheme.ANSI[spec.Code]
+		}
+		return spec
+	}
+
+	var body strings.Builder
+	for _, seg := range ParseSegments(s) {
+		st := seg.Style
+		if st.IsZero() {
+			rtfEscape(&body, seg.Text)
+			continue
+		}
+		var fg, bg color.Color
+		if st.Fg != nil {
+			fg = rgb(st.Fg)
+		}
+		if st.Bg != nil {
+			bg = rgb(st.Bg)
+		}
+		if st.Reverse {
+			if fg == nil {
+				fg = fgDefault
+			}
+			if bg == nil {
+				bg = bgDefault
+			}
+			fg, bg = bg, fg
+		}
+
+		body.WriteString("{")
+		if fg != nil {
+			fmt.Fprintf(&body, `\cf%d`, colorIndex(fg))
+		}
+		if bg != nil {
+			i := colorIndex(bg)
+			fmt.Fprintf(&body, `\chshdng0\chcbpat%d\cb%d`, i, i)
+		}
+		for _, a := range []struct {
+			on   bool
+			word string
+		}{
+			{st.Bold, `\b`},
+			{st.Italic, `\i`},
+			{st.Underline, `\ul`},
+			{st.CrossedOut, `\strike`},
+			{st.Concealed, `\v`},
+		} {
+			if a.on {
+				body.WriteString(a.word)
+			}
+		}
+		body.WriteString(" ")
+		rtfEscape(&body, seg.Text)
+		body.WriteString("}")
+	}
+
+	var b strings.Builder
+	b.WriteString(`{\rtf1\ansi\deff0{\fonttbl{\f0\fmodern `)
+	rtfEscape(&b, o.Font)
+	b.WriteString(`;}}`)
+	b.WriteString(`{\colortbl;`)
+	for _, c := range colors {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&b, `\red%d\green%d\blue%d;`, r>>8, g>>8, bl>>8)
+	}
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, `\f0\fs%d `, 2*o.FontSize)
+	b.WriteString(body.String())
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// rtfEscape writes s to b escaped for RTF: the control characters of RTF
+// are quoted, and the non-ASCII characters are written as \u
+func rtfEscape(b *strings.Builder, s string) {
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '{' || r == '}':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString("\\line\n")
+		case r == '\t':
+			b.WriteString(`\tab `)
+		case r == '\r':
+		case r < 0x80:
+			b.WriteRune(r)
+		case r < 0x10000:
+			fmt.Fprintf(b, `\u%d?`, int16(r))
+		default: // a surrogate pair
+			r -= 0x10000
+			fmt.Fprintf(b, `\u%d?\u%d?`, int16(0xd800+(r>>10)), int16(0xdc00+(r&0x3ff)))
+		}
+	}
+}
+
+// ToMarkdown converts the SGR sequences of s into Markdown: bold, italic
+// and crossed-out text keep their emphasis and the http, https, mailto and
+// file hyperlinks become links, the colors and other attributes are
+// dropped, as is concealed text. The Markdown characters of the text are
+// escaped and its lines end with hard line breaks.
+func ToMarkdown(s string) string {
+	lines := []string{""}
+	for _, seg := range textSegments(s, false) {
+		for i, text := range strings.Split(seg.Text, "\n") {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			var b strings.Builder
+			markdownText(&b, text, seg.Style, strings.TrimSpace(lines[len(lines)-1]) == "")
+			lines[len(lines)-1] += b.String()
+		}
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			// a backslash before a blank line would be literal
+			if strings.TrimSpace(lines[i-1]) != "" && strings.TrimSpace(line) != "" {
+				b.WriteString("\\")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// markdownURL escapes the characters of a URL which would end a Markdown link
+var markdownURL = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E")
+
+// markdownText writes text, without newlines, in style st; the emphasis
+// markers must be next to the text, so the spaces around it are left out.
+// At the start of a line, lineStart, the markers of lists are escaped too.
+func markdownText(b *strings.Builder, text string, st SegmentStyle, lineStart bool) {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		b.WriteString(text)
+		return
+	}
+	start := strings.Index(text, trimmed)
+	var open string
+	if st.Bold {
+		open += "**"
+	}
+	if st.Italic {
+		open += "*"
+	}
+	if st.CrossedOut {
+		open += "~~"
+	}
+	b.WriteString(text[:start])
+	if st.Link != nil {
+		b.WriteString("[")
+	}
+	b.WriteString(open)
+	list := -1 // the index of the list marker to escape
+	if lineStart && open == "" && st.Link == nil {
+		list = markdownListMarker(trimmed)
+	}
+	for i, r := range trimmed {
+		if i == list || strings.ContainsRune("\\`*_~[]<>#|!", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	for i := len(open) - 1; i >= 0; i-- { // the markers reversed
+		b.WriteByte(open[i])
+	}
+	if st.Link != nil {
+		b.WriteString("](" + markdownURL.Replace(st.Link.URL) + ")")
+	}
+	b.WriteString(text[start+len(trimmed):])
+}
+
+// markdownListMarker returns the index of the character of s which makes a
+// line starting with s a list item, e.g. "-" or the "." of "1.", or -1
+func markdownListMarker(s string) int {
+	if s[0] == '-' || s[0] == '+' {
+		return 0
+	}
+	i := 0
+	for i < len(s) && i < 9 && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i > 0 && i < len(s) && (s[i] == '.' || s[i] == ')') {
+		return i
+	}
+	return -1
+}
+
+// ToBBCode converts the SGR sequences of s into BBCode for forums: bold,
+// italic, underlined and crossed-out text keep their tags and the http,
+// https, mailto and file hyperlinks become url tags, the colors and other
+// attributes are dropped, as is concealed text. The text with brackets is
+// put in noparse tags, so it is not read as tags.
+func ToBBCode(s string) string {
+	var b strings.Builder
+	for _, seg := range textSegments(s, true) {
+		var tags []string
+		if seg.Style.Bold {
+			tags = append(tags, "b")
+		}
+		if seg.Style.Italic {
+			tags = append(tags, "i")
+		}
+		if seg.Style.Underline {
+			tags = append(tags, "u")
+		}
+		if seg.Style.CrossedOut {
+			tags = append(tags, "s")
+		}
+		if seg.Style.Link != nil {
+			b.WriteString("[url=" + bbcodeURL.Replace(seg.Style.Link.URL) + "]")
+		}
+		for _, t := range tags {
+			b.WriteString("[" + t + "]")
+		}
+		bbcodeText(&b, seg.Text)
+		for i := len(tags) - 1; i >= 0; i-- {
+			b.WriteString("[/" + tags[i] + "]")
+		}
+		if seg.Style.Link != nil {
+			b.WriteString("[/url]")
+		}
+	}
+	return b.String()
+}
+
+// bbcodeURL escapes the characters of a URL which would end a BBCode tag
+var bbcodeURL = strings.NewReplacer("[", "%5B", "]", "%5D")
+
+// noparseEnd matches the tag ending a noparse tag, in any case
+var noparseEnd = regexp.MustCompile(`(?i)\[/noparse\]`)
+
+// bbcodeText writes text to b, in a noparse tag if it has brackets which
+// could be read as tags; an end of noparse in the text is split apart
+func bbcodeText(b *strings.Builder, text string) {
+	if !strings.Contains(text, "[") {
+		b.WriteString(text)
+		return
+	}
+	b.WriteString("[noparse]")
+	b.WriteString(noparseEnd.ReplaceAllStringFunc(text, func(end string) string {
+		return "[/noparse][noparse][[/noparse][noparse]" + end[1:]
+	}))
+	b.WriteString("[/noparse]")
+}
+
+// textSegments returns the segments of s with only bold, italic, crossed-out,
+// the links to safe URLs (see exportable) and, if underline, underline kept,
+// the adjacent segments in the same style joined; the concealed text is
+// dropped
+func textSegments(s string, underline bool) []Segment {
+	var segs []Segment
+	for _, seg := range ParseSegments(s) {
+		if seg.Style.Concealed {
+			continue
+		}
+		st := SegmentStyle{
+			Bold:       seg.Style.Bold,
+			Italic:     seg.Style.Italic,
+			Underline:  underline && seg.Style.Underline,
+			CrossedOut: seg.Style.CrossedOut,
+		}
+		if seg.Style.Link.exportable() {
+			st.Link = seg.Style.Link
+		}
+		if n := len(segs); n > 0 && segs[n-1].Style.equal(st) {
+			segs[n-1].Text += seg.Text
+		} else {
+			segs = append(segs, Segment{Text: seg.Text, Style: st})
+		}
+	}
+	return segs
+}
@@ -0,0 +1,88 @@
+package pencil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+func TestToRTF(t *testing.T) {
+	scheme, _ := ansirgb.CurrentScheme()
+	o := RTFOptions{Scheme: scheme}
+	out := o.ToRTF("a\\{b}\x1b[1;31mred\x1b[0m \x1b[3;38;2;0;0;255mblue\x1b[0m \x1b[31mred again\x1b[0m\tté😀\n")
+
+	if !strings.HasPrefix(out, `{\rtf1\ansi\deff0{\fonttbl{\f0\fmodern Courier New;}}`) {
+		t.Errorf("ToRTF() = %q, want the font table of Courier New", out)
+	}
+	r, g, b, _ := scheme.ANSI[1].RGBA()
+	red := rtfColor(r>>8, g>>8, b>>8)
+	// the red used twice has a single entry
+	if want := `{\colortbl;` + red + `\red0\green0\blue255;}`; !strings.Contains(out, want) {
+		t.Errorf("ToRTF() = %q, want the color table %q", out, want)
+	}
+	for _, want := range []string{
+		`\f0\fs20 a\\\{b\}`,
+		`{\cf1\b red}`,
+		`{\cf2\i blue}`,
+		`{\cf1 red again}`,
+		`\tab t\u233?\u-10179?\u-8704?\line`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ToRTF() = %q, want %q", out, want)
+		}
+	}
+
+	// the default colors take the place of the missing ones in reverse video
+	out = o.ToRTF("\x1b[7mx\x1b[0m")
+	if !strings.Contains(out, `{\cf1\chshdng0\chcbpat2\cb2 x}`) {
+		t.Errorf("ToRTF() of reverse video = %q", out)
+	}
+}
+
+func rtfColor(r, g, b uint32) string {
+	return fmt.Sprintf(`\red%d\green%d\blue%d;`, r, g, b)
+}
+
+func TestToMarkdown(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"plain", "plain"},
+		{"\x1b[1mbold\x1b[0m and \x1b[3mitalic\x1b[0m", "**bold** and *italic*"},
+		{"\x1b[1;3mboth\x1b[23m bold\x1b[0m", "***both*** **bold**"},
+		{"\x1b[9m crossed \x1b[0m", " ~~crossed~~ "},
+		{"\x1b[31mred\x1b[0m \x1b[8mhidden\x1b[0m", "red "},
+		{"a*b_c [d](e) <f> #g", `a\*b\_c \[d\](e) \<f\> \#g`},
+		{"- a\n+ b\n12. c\n3) d\n> e\n  - f", `\- a\` + "\n" + `\+ b\` + "\n" + `12\. c\` + "\n" + `3\) d\` + "\n" + `\> e\` + "\n" + `  \- f`},
+		{"a - b 1. c", "a - b 1. c"},
+		{"\x1b[1m-\x1b[0m x", "**-** x"},
+		{"one\n\ntwo", "one\n\ntwo"},
+		{"\x1b]8;;https://example.com/a b\x1b\\\x1b[1mlink\x1b[0m\x1b]8;;\x1b\\", "[**link**](https://example.com/a%20b)"},
+	}
+	for _, tt := range tests {
+		if got := ToMarkdown(tt.s); got != tt.want {
+			t.Errorf("ToMarkdown(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestToBBCode(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"plain", "plain"},
+		{"\x1b[1;3;4;9mall\x1b[0m", "[b][i][u][s]all[/s][/u][/i][/b]"},
+		{"\x1b[1mbold \x1b[3mboth\x1b[0m", "[b]bold [/b][b][i]both[/i][/b]"},
+		{"\x1b[31mred\x1b[0m\x1b[8mhidden\x1b[0m", "red"},
+		{"[url=http://evil]x[/url]", "[noparse][url=http://evil]x[/url][/noparse]"},
+		{"a[/NoParse]b", "[noparse]a[/noparse][noparse][[/noparse][noparse]/NoParse]b[/noparse]"},
+		{"\x1b]8;;https://example.com/[x]\x1b\\\x1b[1mlink\x1b[0m\x1b]8;;\x1b\\", "[url=https://example.com/%5Bx%5D][b]link[/b][/url]"},
+	}
+	for _, tt := range tests {
+		if got := ToBBCode(tt.s); got != tt.want {
+			t.Errorf("ToBBCode(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package pencil
+
+import (
+	"bytes"
+	"io"
+)
+
+// maxPending limits the bytes of an unterminated escape sequence kept by an
+// escape filter between writes; a longer sequence is dropped up to its end
+const maxPending = 4096
+
+// escapeScanner splits a stream into text and escape sequences. A sequence
+// split across writes is held back until it is complete.
+type escapeScanner struct {
+	pending []byte
+	// discard is the start of a sequence longer than maxPending, enough to
+	// find its end; the sequence is dropped until then
+	discard []byte
+}
+
+// scan calls text for each run of text of p and seq for each escape sequence,
+// in order; it stops at the first error returned by them
+func (s *escapeScanner) scan(p []byte, text, seq func(b []byte) error) error {
+	data := p
+	switch {
+	case s.discard != nil:
+		data = append(s.discard, p...)
+		s.discard = nil
+		n, ok := escapeLen(data)
+		if !ok {
+			s.discard = sequenceStart(data)
+			return nil
+		}
+		data = data[n:]
+	case len(s.pending) > 0:
+		data = append(s.pending, p...)
+		s.pending = nil
+	}
+
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, 0x1b)
+		if i < 0 {
+			return text(data)
+		}
+		if i > 0 {
+			if err := text(data[:i]); err != nil {
+				return err
+			}
+		}
+		data = data[i:]
+		n, ok := escapeLen(data)
+		if !ok {
+			if len(data) <= maxPending {
+				s.pending = append([]byte(nil), data...)
+			} else {
+				s.discard = sequenceStart(data)
+			}
+			return nil
+		}
+		if err := seq(data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// sequenceStart returns what escapeLen needs of the incomplete sequence b to
+// find its end in the bytes following b: its introducer, and a final ESC
+// which may start its terminator
+func sequenceStart(b []byte) []byte {
+	start := append([]byte(nil), b[:2]...)
+	if len(b) > 2 && b[len(b)-1] == 0x1b {
+		start = append(start, 0x1b)
+	}
+	return start
+}
+
+// escapeFilter is an io.Writer passing the text written to it to w and the
+// escape sequences through rewrite, which returns the bytes to write instead
+// of the sequence (nil to remove it).
+type escapeFilter struct {
+	escapeScanner
+	w       io.Writer
+	rewrite func(seq []byte) []byte
+	buf     bytes.Buffer
+}
+
+// Write implements io.Writer; it returns len(p) if the filtered bytes have
+// been written to the underlying writer.
+func (f *escapeFilter) Write(p []byte) (int, error) {
+	f.buf.Reset()
+	f.scan(p, func(b []byte) error {
+		f.buf.Write(b)
+		return nil
+	}, func(seq []byte) error {
+		f.buf.Write(f.rewrite(seq))
+		return nil
+	})
+
+	if f.buf.Len() > 0 {
+		if _, err := f.w.Write(f.buf.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// StripWriter is an io.Writer removing all escape sequences from whatever is
+// written to it, e.g. to pipe colored output into a log file.
+type StripWriter struct {
+	escapeFilter
+}
+
+// NewStripWriter returns a StripWriter writing to w
+func NewStripWriter(w io.Writer) *StripWriter {
+	return &StripWriter{escapeFilter{
+		w:       w,
+		rewrite: func(seq []byte) []byte { return nil },
+	}}
+}
+
+// ColorProfile implements Profiler: nothing written to a StripWriter is
+// colorized
+func (s *StripWriter) ColorProfile() Profile {
+	return Profile{NoColor: true, Mode: ModeANSI8}
+}
+
+// DowngradeWriter is an io.Writer rewriting the 24-bit and 256-colors SGR
+// sequences written to it into a poorer color mode, e.g. for terminals
+// supporting the basic colors only. Other sequences pass unchanged.
+type DowngradeWriter struct {
+	escapeFilter
+	mode ColorMode
+}
+
+// NewDowngradeWriter returns a DowngradeWriter writing to w, converting
+// colors to mode
+func NewDowngradeWriter(w io.Writer, mode ColorMode) *DowngradeWriter {
+	d := &DowngradeWriter{mode: mode}
+	d.escapeFilter = escapeFilter{w: w, rewrite: d.rewrite}
+	return d
+}
+
+func (d *DowngradeWriter) rewrite(seq []byte) []byte {
+	if !isSGR(seq) {
+		return seq
+	}
+	params := string(seq[2 : len(seq)-1])
+	converted := downgradeSGR(params, d.mode)
+	if converted == params {
+		return seq
+	}
+	if converted == "" {
+		// every parameter was dropped; an empty SGR would reset
+		return nil
+	}
+	return []byte(Escape + "[" + converted + "m")
+}
+
+// ColorProfile implements Profiler: colors written to a DowngradeWriter are
+// converted to its mode anyway, so they are written in that mode directly
+func (d *DowngradeWriter) ColorProfile() Profile {
+	p, _ := ProfileOf(d.w)
+	if p.Mode > d.mode {
+		p.Mode = d.mode
+	}
+	return p
+}
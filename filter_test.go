@@ -0,0 +1,102 @@
+package pencil
+
+import (
+	"strings"
+	"testing"
+)
+
+// writeChunks writes s to w in chunks of n bytes
+func writeChunks(t *testing.T, w interface{ Write([]byte) (int, error) }, s string, n int) {
+	t.Helper()
+	for len(s) > 0 {
+		k := n
+		if k > len(s) {
+			k = len(s)
+		}
+		if m, err := w.Write([]byte(s[:k])); m != k || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s[:k], m, err)
+		}
+		s = s[k:]
+	}
+}
+
+func TestStripWriter(t *testing.T) {
+	in := "\x1b[1;31mred\x1b[0m \x1b]8;;https://example.com\x1b\\link\x1b]8;;\a \x1b(Bend\n"
+	for _, n := range []int{len(in), 1, 2, 3, 5} {
+		var b strings.Builder
+		writeChunks(t, NewStripWriter(&b), in, n)
+		if got, want := b.String(), "red link end\n"; got != want {
+			t.Errorf("chunks of %d: StripWriter wrote %q, want %q", n, got, want)
+		}
+	}
+	if p := NewStripWriter(nil).ColorProfile(); !p.NoColor {
+		t.Errorf("StripWriter.ColorProfile() = %+v, want NoColor", p)
+	}
+}
+
+func TestStripWriterLongSequence(t *testing.T) {
+	// a sequence longer than maxPending is dropped up to its end, even if
+	// its terminator is split across writes
+	url := strings.Repeat("x", 3*maxPending)
+	for _, term := range []string{"\x1b\\", "\a"} {
+		in := "a\x1b]8;;" + url + term + "b\x1b[1mc"
+		for _, n := range []int{len(in), maxPending - 1, maxPending + 1, 1000, 7} {
+			var b strings.Builder
+			writeChunks(t, NewStripWriter(&b), in, n)
+			if got := b.String(); got != "abc" {
+				t.Errorf("terminator %q, chunks of %d: StripWriter wrote %q, want %q", term, n, shorten(got), "abc")
+			}
+		}
+	}
+
+	// another sequence aborting the long one is kept
+	var b strings.Builder
+	w := NewStripWriter(&b)
+	writeChunks(t, w, "a\x1b]"+url, maxPending)
+	writeChunks(t, w, "\x1b", 1)
+	writeChunks(t, w, "[1mb", 4)
+	if got := b.String(); got != "ab" {
+		t.Errorf("StripWriter wrote %q, want %q", shorten(got), "ab")
+	}
+}
+
+// shorten returns s cut to 40 bytes
+func shorten(s string) string {
+	if len(s) > 40 {
+		return s[:40] + "..."
+	}
+	return s
+}
+
+func TestDowngradeWriter(t *testing.T) {
+	in := "\x1b[1;38;2;255;136;0mx\x1b[0m \x1b[48;5;196my\x1b[0m \x1b[58;5;1;4mz\x1b[0m\x1b]8;;https://example.com\x1b\\w"
+	tests := []struct {
+		mode ColorMode
+		want string
+	}{
+		{ModeRGB, in},
+		{ModeANSI256, "\x1b[1;38;5;208mx\x1b[0m \x1b[48;5;196my\x1b[0m \x1b[58;5;1;4mz\x1b[0m\x1b]8;;https://example.com\x1b\\w"},
+		{ModeANSI8, "\x1b[1;33mx\x1b[0m \x1b[101my\x1b[0m \x1b[4mz\x1b[0m\x1b]8;;https://example.com\x1b\\w"},
+	}
+	for _, tt := range tests {
+		for _, n := range []int{len(in), 1, 4} {
+			var b strings.Builder
+			writeChunks(t, NewDowngradeWriter(&b, tt.mode), in, n)
+			if got := b.String(); got != tt.want {
+				t.Errorf("mode %v, chunks of %d: DowngradeWriter wrote %q, want %q", tt.mode, n, got, tt.want)
+			}
+		}
+	}
+
+	// an SGR left empty is dropped rather than written as a reset
+	var b strings.Builder
+	NewDowngradeWriter(&b, ModeANSI8).Write([]byte("\x1b[58;5;1mx"))
+	if got := b.String(); got != "x" {
+		t.Errorf("DowngradeWriter wrote %q, want %q", got, "x")
+	}
+
+	p := NewDowngradeWriter(NewWriterProfile(&b, Profile{Mode: ModeRGB, Hyperlinks: true}), ModeANSI256).ColorProfile()
+	if p != (Profile{Mode: ModeANSI256, Hyperlinks: true}) {
+		t.Errorf("DowngradeWriter.ColorProfile() = %+v, want the 256 colors", p)
+	}
+}
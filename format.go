@@ -0,0 +1,77 @@
+package pencil
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Sprinter is implemented by the colors of ansi8, ansi256 and rgb16b as well
+// as by Style
+type Sprinter interface {
+	Sprint(a ...interface{}) string
+}
+
+// StyledValue is a value printed in a style by the fmt functions; see Styled
+type StyledValue struct {
+	Value interface{}
+	Style Sprinter
+}
+
+// Styled returns x to be printed in style by the verbs of the fmt functions.
+// The width pads the colored text by its visible width, outside of the
+// colors, so columns line up:
+//
+//	red := ansi8.New(ansi8.FgRed)
+//	fmt.Printf("%-10v|%6.2f\n", pencil.Styled("failed", red), pencil.Styled(3.14159, red))
+func Styled(x interface{}, style Sprinter) StyledValue {
+	return StyledValue{Value: x, Style: style}
+}
+
+// Format implements fmt.Formatter: Value is formatted by verb with the flags
+// and the precision of f, colored by Style, then padded to the width of f.
+// The flag '0' pads inside of the colors, as it is part of the number.
+func (v StyledValue) Format(f fmt.State, verb rune) {
+	width, hasWidth := f.Width()
+	inner := hasWidth && f.Flag('0') && !f.Flag('-')
+
+	text := fmt.Sprintf(formatDirective(f, verb, inner), v.Value)
+	if v.Style != nil {
+		text = v.Style.Sprint(text)
+	}
+	if pad := width - VisibleWidth(text); hasWidth && !inner && pad > 0 {
+		if f.Flag('-') {
+			text += strings.Repeat(" ", pad)
+		} else {
+			text = strings.Repeat(" ", pad) + text
+		}
+	}
+	io.WriteString(f, text)
+}
+
+// String returns Value formatted by %v and colored by Style
+func (v StyledValue) String() string {
+	return fmt.Sprint(v)
+}
+
+// formatDirective rebuilds the directive of f and verb, e.g. "%+.2f"; the
+// width is kept only if withWidth is true
+func formatDirective(f fmt.State, verb rune, withWidth bool) string {
+	var b strings.Builder
+	b.WriteByte('%')
+	for _, flag := range "+-# 0" {
+		if f.Flag(int(flag)) {
+			b.WriteRune(flag)
+		}
+	}
+	if w, ok := f.Width(); ok && withWidth {
+		b.WriteString(strconv.Itoa(w))
+	}
+	if p, ok := f.Precision(); ok {
+		b.WriteByte('.')
+		b.WriteString(strconv.Itoa(p))
+	}
+	b.WriteRune(verb)
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package pencil
+
+import (
+	"fmt"
+	"testing"
+)
+
+// brackets is a Sprinter showing where the style applies
+type brackets struct{}
+
+func (brackets) Sprint(a ...interface{}) string {
+	return "[" + fmt.Sprint(a...) + "]"
+}
+
+func TestStyledFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		value  interface{}
+		want   string
+	}{
+		{"%v", "failed", "[failed]"},
+		{"%-10v|", "failed", "[failed]  |"},
+		{"%10v|", "failed", "  [failed]|"},
+		{"%3v|", "failed", "[failed]|"},
+		// the zeros of '0' are part of the number, so inside the style
+		{"%05d", 42, "[00042]"},
+		{"%-05d|", 42, "[42] |"},
+		{"%.2f", 3.14159, "[3.14]"},
+		{"%8.2f|", 3.14159, "  [3.14]|"},
+		{"%+d", 5, "[+5]"},
+		{"%+v", struct{ A int }{1}, "[{A:1}]"},
+		{"%q", `a"b`, `["a\"b"]`},
+		{"%6q|", "é", ` ["é"]|`},
+		{"%#x", 255, "[0xff]"},
+		{"%d", "x", "[%!d(string=x)]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprintf(tt.format, Styled(tt.value, brackets{})); got != tt.want {
+			t.Errorf("Sprintf(%q, %v) = %q, want %q", tt.format, tt.value, got, tt.want)
+		}
+	}
+
+	if got := fmt.Sprintf("%3d|", Styled(1, nil)); got != "  1|" {
+		t.Errorf("Sprintf() without a style = %q, want %q", got, "  1|")
+	}
+	if got := Styled("x", brackets{}).String(); got != "[x]" {
+		t.Errorf("String() = %q, want %q", got, "[x]")
+	}
+}
+
+func TestStyledFormatEscapes(t *testing.T) {
+	// the width counts the visible text only, and the padding is outside of
+	// the colors
+	defer func(nc bool, p Profile) { NoColor, ColorProfile = nc, p }(NoColor, ColorProfile)
+	NoColor, ColorProfile = false, Profile{Mode: ModeANSI8}
+	st := NewStyle(ANSIColor(1))
+	if got, want := fmt.Sprintf("%-5v|%4v|", Styled("ab", st), Styled("日本", st)), "\x1b[31mab\x1b[0m   |\x1b[31m日本\x1b[0m|"; got != want {
+		t.Errorf("Sprintf() = %q, want %q", got, want)
+	}
+}
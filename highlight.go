@@ -0,0 +1,322 @@
+package pencil
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	// UUIDPattern matches UUIDs, e.g. "123e4567-e89b-12d3-a456-426614174000"
+	UUIDPattern = regexp.MustCompile(`\b[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}\b`)
+	// AddressPattern matches IPv4 addresses, with their prefix length or
+	// port if any, e.g. "10.0.0.1:8080", and IPv6 addresses, e.g. "fe80::1";
+	// a compressed IPv6 address needs a group after "::", so that the scopes
+	// of code, e.g. "add::" or "std::add", are not taken for addresses
+	AddressPattern = regexp.MustCompile(
+		`\b(?:\d{1,3}\.){3}\d{1,3}(?:/\d{1,2}|:\d{1,5})?\b` +
+			`|\b(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}\b` +
+			`|\b(?:[0-9A-Fa-f]{1,4}:){1,6}:[0-9A-Fa-f]{1,4}(?::[0-9A-Fa-f]{1,4}){0,5}\b` +
+			`|\B::[0-9A-Fa-f]{1,4}(?::[0-9A-Fa-f]{1,4}){0,6}\b`)
+	// DurationPattern matches durations as printed by time.Duration, e.g.
+	// "1h2m3.5s" or "250ms"
+	DurationPattern = regexp.MustCompile(`\b(?:\d+(?:\.\d+)?(?:ns|us|µs|ms|s|m|h))+\b`)
+)
+
+// Style names of DefaultHighlighter, refined by "highlight."
+const (
+	StyleUUID     = "uuid"
+	StyleAddress  = "address"
+	StyleDuration = "duration"
+)
+
+func init() {
+	DefaultTheme.
+		Set(StyleUUID, NewStyle(ANSIColor(5))).
+		Set(StyleAddress, NewStyle(ANSIColor(6))).
+		Set(StyleDuration, NewStyle(ANSIColor(3)))
+}
+
+// HighlightRule styles the matches of Pattern in Style. Groups styles the
+// capture groups 1, 2, ... of Pattern over Style; a zero style leaves its
+// group as the rest of the match. The links of the styles are not written.
+type HighlightRule struct {
+	Pattern *regexp.Regexp
+	Style   Style
+	Groups  []Style
+}
+
+// Highlighter styles the matches of regular expressions in text, e.g. to
+// color the IP addresses of a log:
+//
+//	h := pencil.NewHighlighter().
+//		Add(`\berror\b`, pencil.NewStyle(pencil.ANSIColor(1), pencil.Bold)).
+//		Add(`(\w+)=(\S+)`, pencil.Style{}, pencil.NewStyle(pencil.ANSIColor(4)))
+//	fmt.Println(h.Highlight(line))
+//
+// The rules apply in order: a match overlapping one of a previous rule, or a
+// previous match of the same rule, is skipped. The escape sequences already
+// in the text are kept whole and the colors they set are restored after
+// each match.
+type Highlighter struct {
+	Rules []HighlightRule
+}
+
+// NewHighlighter returns a Highlighter applying rules
+func NewHighlighter(rules ...HighlightRule) *Highlighter {
+	return &Highlighter{Rules: rules}
+}
+
+// DefaultHighlighter returns a Highlighter of the UUIDs, IP addresses and
+// durations, in the styles "highlight.uuid", "highlight.address" and
+// "highlight.duration" of the theme t, CurrentTheme() if nil
+func DefaultHighlighter(t *Theme) *Highlighter {
+	if t == nil {
+		t = CurrentTheme()
+	}
+	return NewHighlighter(
+		HighlightRule{Pattern: UUIDPattern, Style: t.Style("highlight." + StyleUUID)},
+		HighlightRule{Pattern: AddressPattern, Style: t.Style("highlight." + StyleAddress)},
+		HighlightRule{Pattern: DurationPattern, Style: t.Style("highlight." + StyleDuration)},
+	)
+}
+
+// Add appends a rule styling the matches of the regular expression expr; it
+// panics if expr does not compile, like regexp.MustCompile
+func (h *Highlighter) Add(expr string, style Style, groups ...Style) *Highlighter {
+	h.Rules = append(h.Rules, HighlightRule{Pattern: regexp.MustCompile(expr), Style: style, Groups: groups})
+	return h
+}
+
+// Highlight returns s with the matches of the rules styled in the color mode
+// of the package
+func (h *Highlighter) Highlight(s string) string {
+	return h.HighlightFor(defaultProfile(), s)
+}
+
+// HighlightFor returns s with the matches of the rules styled for the
+// profile p; s is returned as it is if p disables colors
+func (h *Highlighter) HighlightFor(p Profile, s string) string {
+	var active SegmentStyle
+	return h.highlight(p, s, &active)
+}
+
+// inputSeq is an escape sequence of the text to highlight, at the offset at
+// of its visible text
+type inputSeq struct {
+	at  int
+	seq string
+}
+
+// highlight returns s with the matches styled for p; active holds the style
+// set by the SGR sequences of the input, updated with those of s, so it can
+// be restored after a match
+func (h *Highlighter) highlight(p Profile, s string, active *SegmentStyle) string {
+	if p.NoColor || len(h.Rules) == 0 || s == "" {
+		return s
+	}
+
+	// the rules match the visible text: the escape sequences are set apart
+	// and written back at their places
+	text, seqs := s, []inputSeq(nil)
+	if strings.IndexByte(s, 0x1b) >= 0 {
+		var b strings.Builder
+		for i := 0; i < len(s); {
+			j := strings.IndexByte(s[i:], 0x1b)
+			if j < 0 {
+				b.WriteString(s[i:])
+				break
+			}
+			b.WriteString(s[i : i+j])
+			i += j
+			n, ok := escapeLen([]byte(s[i:]))
+			if !ok {
+				n = len(s) - i
+			}
+			seqs = append(seqs, inputSeq{b.Len(), s[i : i+n]})
+			i += n
+		}
+		text = b.String()
+	}
+
+	// marks holds for each byte of text the index in styles of its
+	// sequence, -1 if it is not part of a match
+	marks := make([]int, len(text))
+	for i := range marks {
+		marks[i] = -1
+	}
+	var styles []string
+	for _, r := range h.Rules {
+		if r.Pattern == nil {
+			continue
+		}
+		seq := ""
+		if !r.Style.IsZero() {
+			seq = r.Style.SequenceFor(p.Mode)
+		}
+		matches := r.Pattern.FindAllStringSubmatchIndex(text, -1)
+	match:
+		for _, m := range matches {
+			if m[1] <= m[0] {
+				continue
+			}
+			for _, k := range marks[m[0]:m[1]] {
+				if k >= 0 {
+					continue match
+				}
+			}
+			styles = append(styles, seq)
+			fill(marks[m[0]:m[1]], len(styles)-1)
+			for g, gs := range r.Groups {
+				if 2*g+3 >= len(m) || m[2*g+2] < 0 || gs.IsZero() {
+					continue
+				}
+				styles = append(styles, seq+gs.SequenceFor(p.Mode))
+				fill(marks[m[2*g+2]:m[2*g+3]], len(styles)-1)
+			}
+		}
+	}
+	if len(styles) == 0 {
+		for _, is := range seqs {
+			trackSGR(active, is.seq)
+		}
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 16*len(styles))
+	style := func(k int) string {
+		if k < 0 {
+			return ""
+		}
+		return styles[k]
+	}
+	cur, next := -1, 0
+	for i := 0; i <= len(text); i++ {
+		k := -1
+		if i < len(text) {
+			k = marks[i]
+		}
+		if k != cur && style(cur) != "" {
+			b.WriteString(GetRest())
+			b.WriteString(active.sequence())
+		}
+		for ; next < len(seqs) && seqs[next].at == i; next++ {
+			b.WriteString(seqs[next].seq)
+			trackSGR(active, seqs[next].seq)
+			if k == cur {
+				b.WriteString(style(cur))
+			}
+		}
+		if k != cur {
+			b.WriteString(style(k))
+			cur = k
+		}
+		if i < len(text) {
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
+
+// fill sets all the elements of a to k
+func fill(a []int, k int) {
+	for i := range a {
+		a[i] = k
+	}
+}
+
+// trackSGR updates the style active with seq if it is an SGR sequence; only
+// the effective state is kept, however many sequences set it
+func trackSGR(active *SegmentStyle, seq string) {
+	if isSGR([]byte(seq)) {
+		*active = applySGR(*active, seq[2:len(seq)-1])
+	}
+}
+
+// HighlightWriter is an io.Writer highlighting the lines written to it:
+//
+//	log.SetOutput(pencil.DefaultHighlighter(nil).NewWriter(os.Stderr))
+//
+// The rules match within lines; the colors set by the escape sequences
+// written are followed across lines.
+type HighlightWriter struct {
+	Profile     Profile
+	Highlighter *Highlighter
+
+	w       io.Writer
+	pending []byte // an incomplete line
+	active  SegmentStyle
+	mu      sync.Mutex
+}
+
+// NewWriter returns a HighlightWriter of h writing to w, with the profile
+// detected from w (see NewWriter)
+func (h *Highlighter) NewWriter(w io.Writer) *HighlightWriter {
+	return &HighlightWriter{Profile: NewWriter(w).Profile, Highlighter: h, w: w}
+}
+
+// Write implements io.Writer. Complete lines are written at once; the rest
+// is held back until its newline or Flush. If the underlying writer fails,
+// Write returns 0 and the lines held back before p are kept.
+func (hw *HighlightWriter) Write(p []byte) (int, error) {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+
+	if hw.Profile.NoColor && len(hw.pending) == 0 {
+		return hw.w.Write(p)
+	}
+
+	prev := hw.pending
+	data := append(prev[:len(prev):len(prev)], p...)
+	hw.pending = nil
+	i := bytes.LastIndexByte(data, '\n')
+	if i < 0 {
+		hw.pending = data
+		return len(p), nil
+	}
+	if i+1 < len(data) {
+		hw.pending = append([]byte(nil), data[i+1:]...)
+	}
+
+	var buf bytes.Buffer
+	active := hw.active
+	for _, line := range bytes.SplitAfter(data[:i+1], []byte("\n")) {
+		if len(line) > 0 {
+			buf.WriteString(hw.Highlighter.highlight(hw.Profile, string(line[:len(line)-1]), &active))
+			buf.WriteByte('\n')
+		}
+	}
+	if _, err := hw.w.Write(buf.Bytes()); err != nil {
+		// nothing of p is taken, so writing p again does not repeat it
+		hw.pending = prev
+		return 0, err
+	}
+	hw.active = active
+	return len(p), nil
+}
+
+// Flush writes the incomplete line held back by Write; the line is kept if
+// the underlying writer fails
+func (hw *HighlightWriter) Flush() error {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+
+	if len(hw.pending) == 0 {
+		return nil
+	}
+	active := hw.active
+	line := hw.Highlighter.highlight(hw.Profile, string(hw.pending), &active)
+	if _, err := io.WriteString(hw.w, line); err != nil {
+		return err
+	}
+	hw.pending, hw.active = nil, active
+	return nil
+}
+
+// ColorProfile implements Profiler
+func (hw *HighlightWriter) ColorProfile() Profile {
+	return hw.Profile
+}
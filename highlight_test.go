@@ -0,0 +1,131 @@
+package pencil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	p := Profile{Mode: ModeANSI8}
+	red, blue, cyan := NewStyle(ANSIColor(1)), NewStyle(ANSIColor(4)), NewStyle(ANSIColor(6))
+	tests := []struct {
+		name    string
+		h       *Highlighter
+		s, want string
+	}{
+		{
+			"the first rule wins",
+			NewHighlighter().Add(`\berror\b`, red).Add(`\w+`, blue),
+			"an error", "\x1b[34man\x1b[0m \x1b[31merror\x1b[0m",
+		},
+		{
+			"a match overlapping a previous one is skipped",
+			NewHighlighter().Add(`bc`, red).Add(`abc`, blue).Add(`d`, blue),
+			"abcd", "a\x1b[31mbc\x1b[0m\x1b[34md\x1b[0m",
+		},
+		{
+			"the groups are styled over the match",
+			NewHighlighter().Add(`(\w+)=(\w+)`, NewStyle(nil, Bold), blue, Style{}),
+			"k=v", "\x1b[1m\x1b[34mk\x1b[0m\x1b[1m=v\x1b[0m",
+		},
+		{
+			"a zero style leaves the rest of the match plain",
+			NewHighlighter().Add(`(\w+)=\w+`, Style{}, blue),
+			"k=v", "\x1b[34mk\x1b[0m=v",
+		},
+		{
+			"the escapes of the input are kept and restored",
+			NewHighlighter(HighlightRule{Pattern: AddressPattern, Style: cyan}),
+			"\x1b[32mid 10.0.0.1 ok\x1b[0m", "\x1b[32mid \x1b[36m10.0.0.1\x1b[0m\x1b[32m ok\x1b[0m",
+		},
+		{
+			"an escape within a match is followed by the style of the match",
+			NewHighlighter(HighlightRule{Pattern: AddressPattern, Style: cyan}),
+			"a 10.0\x1b[1m.0.1 b", "a \x1b[36m10.0\x1b[1m\x1b[36m.0.1\x1b[0m\x1b[1m b",
+		},
+		{
+			"no match",
+			NewHighlighter().Add(`x`, red),
+			"\x1b[1mabc", "\x1b[1mabc",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.h.HighlightFor(p, tt.s); got != tt.want {
+			t.Errorf("%s: HighlightFor(%q) = %q, want %q", tt.name, tt.s, got, tt.want)
+		}
+	}
+
+	if got := NewHighlighter().Add(`x`, red).HighlightFor(Profile{NoColor: true}, "x"); got != "x" {
+		t.Errorf("HighlightFor() with NoColor = %q, want %q", got, "x")
+	}
+}
+
+func TestHighlightWriterState(t *testing.T) {
+	// the colors set across lines are restored after a match as the single
+	// sequence of their effective state, however many set them
+	var b strings.Builder
+	hw := NewHighlighter(HighlightRule{Pattern: AddressPattern, Style: NewStyle(ANSIColor(6))}).NewWriter(&b)
+	hw.Profile = Profile{Mode: ModeANSI8}
+	for i := 0; i < 1000; i++ {
+		hw.Write([]byte("\x1b[31m\x1b[1mline\n"))
+	}
+	hw.Write([]byte("\x1b[33m10.0.0.1 z\n"))
+	if n := len(hw.active.sequence()); n > len("\x1b[1;33m") {
+		t.Errorf("the state in effect is %q, want %q", hw.active.sequence(), "\x1b[1;33m")
+	}
+	want := "\x1b[33m\x1b[36m10.0.0.1\x1b[0m\x1b[1;33m z\n"
+	if got := b.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("HighlightWriter did not end with %q", want)
+	}
+
+	// a partial line waits for its newline or Flush
+	b.Reset()
+	hw.Write([]byte("\x1b[0mto 10.0."))
+	if b.Len() != 0 {
+		t.Errorf("HighlightWriter wrote %q of a partial line", b.String())
+	}
+	hw.Write([]byte("0.2"))
+	hw.Flush()
+	if got, want := b.String(), "\x1b[0mto \x1b[36m10.0.0.2\x1b[0m"; got != want {
+		t.Errorf("HighlightWriter wrote %q, want %q", got, want)
+	}
+}
+
+func TestAddressPattern(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"from 10.0.0.1:8080 to 192.168.0.0/16", []string{"10.0.0.1:8080", "192.168.0.0/16"}},
+		{"fe80::1 and ::1", []string{"fe80::1", "::1"}},
+		{"2001:db8::8a2e:370:7334", []string{"2001:db8::8a2e:370:7334"}},
+		{"2001:0db8:85a3:0000:0000:8a2e:0370:7334", []string{"2001:0db8:85a3:0000:0000:8a2e:0370:7334"}},
+		{"add:: std::add Foo::bad a::", nil},
+		{"v1.2.3", nil},
+	}
+	for _, tt := range tests {
+		got := AddressPattern.FindAllString(tt.s, -1)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("AddressPattern in %q = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightWriterError(t *testing.T) {
+	w := &failingWriter{fails: 1}
+	hw := NewHighlighter().Add(`x`, NewStyle(ANSIColor(1))).NewWriter(w)
+	hw.Profile = Profile{Mode: ModeANSI8}
+	hw.Write([]byte("a"))
+	if n, err := hw.Write([]byte("x\nb")); n != 0 || err == nil {
+		t.Fatalf("Write() = %d, %v, want 0 and the error", n, err)
+	}
+	hw.Write([]byte("x\nb"))
+	w.fails = 1
+	if err := hw.Flush(); err == nil {
+		t.Fatal("Flush() = nil, want the error")
+	}
+	hw.Flush()
+	if got, want := w.String(), "a\x1b[31mx\x1b[0m\nb"; got != want {
+		t.Errorf("HighlightWriter wrote %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,269 @@
+package pencil
+
+import (
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+// HTMLOptions are the options of the conversion to HTML
+type HTMLOptions struct {
+	// Classes writes the basic colors (0-15) and the attributes as CSS
+	// classes, e.g. "pencil-fg-1" or "pencil-bold", instead of inline
+	// styles, so a stylesheet can change them; the other colors are always
+	// written inline
+	Classes bool
+	// Standalone writes a complete page, with the stylesheet of the classes,
+	// instead of a fragment
+	Standalone bool
+	// Title is the title of a standalone page
+	Title string
+	// Scheme gives the colors 0-15 and the default colors of the page;
+	// the current scheme of ansirgb if nil
+	Scheme *ansirgb.Scheme
+}
+
+// ToHTML converts the SGR sequences of s, such as the ones written by
+// pencil, into HTML spans with inline styles and the hyperlinks into
+// anchors; the text is escaped and the other escape sequences are dropped.
+// Only the http, https, mailto and file links become anchors, the text of
+// the others is kept plain. The basic and 256-colors are mapped to RGB by
+// ansirgb.Lookup().
+func ToHTML(s string) string {
+	return HTMLOptions{}.ToHTML(s)
+}
+
+// ToHTML is ToHTML with the options o
+func (o HTMLOptions) ToHTML(s string) string {
+	var b strings.Builder
+	w := NewHTMLWriter(&b, &o)
+	io.WriteString(w, s)
+	w.Close()
+	return b.String()
+}
+
+// HTMLWriter is an io.Writer converting what is written to it into HTML, as
+// ToHTML does; Close ends a standalone page. It is a Profiler with
+// ModeRGB and hyperlinks, so colors and links are written to it unchanged.
+type HTMLWriter struct {
+	w       io.Writer
+	opts    HTMLOptions
+	parser  SegmentParser
+	rest    []byte // an incomplete character at the end of the last write
+	started bool
+	mu      sync.Mutex
+}
+
+// NewHTMLWriter returns an HTMLWriter writing to w; opts may be nil
+func NewHTMLWriter(w io.Writer, opts *HTMLOptions) *HTMLWriter {
+	h := &HTMLWriter{w: w}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Scheme == nil {
+		h.opts.Scheme, _ = ansirgb.CurrentScheme()
+	}
+	return h
+}
+
+// Write implements io.Writer
+func (h *HTMLWriter) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	data := append(h.rest, p...)
+	// a character split across writes must not be split across spans
+	h.rest = nil
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				data, h.rest = data[:i], append([]byte(nil), data[i:]...)
+			}
+			break
+		}
+	}
+
+	var b strings.Builder
+	h.start(&b)
+	for _, seg := range h.parser.Parse(data) {
+		h.writeSegment(&b, seg)
+	}
+	if _, err := io.WriteString(h.w, b.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes what is left of an incomplete character and the end of a
+// standalone page; it does not close the underlying writer
+func (h *HTMLWriter) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var b strings.Builder
+	h.start(&b)
+	for _, seg := range h.parser.Parse(h.rest) {
+		h.writeSegment(&b, seg)
+	}
+	h.rest = nil
+	if !h.opts.Standalone {
+		_, err := io.WriteString(h.w, b.String())
+		return err
+	}
+	b.WriteString("</pre>\n</body>\n</html>\n")
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// ColorProfile implements Profiler
+func (h *HTMLWriter) ColorProfile() Profile {
+	return Profile{Mode: ModeRGB, Hyperlinks: true}
+}
+
+// start writes the head of a standalone page before the first content
+func (h *HTMLWriter) start(b *strings.Builder) {
+	if h.started || !h.opts.Standalone {
+		return
+	}
+	h.started = true
+	title := h.opts.Title
+	if title == "" {
+		title = "pencil"
+	}
+	fmt.Fprintf(b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s</style>\n</head>\n<body>\n<pre class=\"pencil\">",
+		html.EscapeString(title), h.opts.Stylesheet())
+}
+
+// Stylesheet returns the CSS of a standalone page: the default colors of
+// the scheme and the classes written with Classes
+func (o HTMLOptions) Stylesheet() string {
+	s := o.Scheme
+	if s == nil {
+		s, _ = ansirgb.CurrentScheme()
+	}
+	fg, bg := schemeDefaults(s)
+	var b strings.Builder
+	fmt.Fprintf(&b, ".pencil { color: %s; background-color: %s; padding: 1em; }\n", cssColor(fg), cssColor(bg))
+	for i, c := range s.ANSI {
+		fmt.Fprintf(&b, ".pencil-fg-%d { color: %s; }\n", i, cssColor(c))
+		fmt.Fprintf(&b, ".pencil-bg-%d { background-color: %s; }\n", i, cssColor(c))
+	}
+	b.WriteString(".pencil-bold { font-weight: bold; }\n" +
+		".pencil-faint { opacity: 0.7; }\n" +
+		".pencil-italic { font-style: italic; }\n" +
+		".pencil-underline { text-decoration: underline; }\n" +
+		".pencil-crossed-out { text-decoration: line-through; }\n" +
+		".pencil-underline.pencil-crossed-out { text-decoration: underline line-through; }\n" +
+		".pencil-concealed { visibility: hidden; }\n")
+	return b.String()
+}
+
+// schemeDefaults returns the default colors of s, or light gray on black if
+// they are unknown
+func schemeDefaults(s *ansirgb.Scheme) (fg, bg color.Color) {
+	fg, bg = s.Foreground, s.Background
+	if fg == nil {
+		fg = s.ANSI[7]
+	}
+	if bg == nil {
+		bg = s.ANSI[0]
+	}
+	return fg, bg
+}
+
+func cssColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+// rgb returns the RGB color of spec, with the colors 0-15 taken from the
+// scheme of the writer
+func (h *HTMLWriter) rgb(spec *ColorSpec) color.Color {
+	if spec.Mode != ModeRGB && spec.Code >= 0 && spec.Code < 16 {
+		return h.opts.Scheme.ANSI[spec.Code]
+	}
+	return spec
+}
+
+// writeSegment writes the text of seg escaped, in a span if its style shows
+// and in an anchor if it is a link to a safe URL (see exportable)
+func (h *HTMLWriter) writeSegment(b *strings.Builder, seg Segment) {
+	text := html.EscapeString(seg.Text)
+	st := seg.Style
+	if st.Link.exportable() {
+		fmt.Fprintf(b, `<a href="%s">`, html.EscapeString(st.Link.URL))
+		defer b.WriteString("</a>")
+	}
+	st.Link = nil
+	if st.IsZero() {
+		b.WriteString(text)
+		return
+	}
+
+	var classes, css []string
+	fgSpec, bgSpec := st.Fg, st.Bg
+	if st.Reverse {
+		fgSpec, bgSpec = bgSpec, fgSpec
+	}
+	fgDefault, bgDefault := schemeDefaults(h.opts.Scheme)
+	colorDecl := func(spec *ColorSpec, kind, prop string, def color.Color, swapped bool) {
+		switch {
+		case spec == nil && !swapped:
+		case spec == nil:
+			css = append(css, prop+": "+cssColor(def))
+		case h.opts.Classes && spec.Mode != ModeRGB && spec.Code >= 0 && spec.Code < 16:
+			classes = append(classes, fmt.Sprintf("pencil-%s-%d", kind, spec.Code))
+		default:
+			css = append(css, prop+": "+cssColor(h.rgb(spec)))
+		}
+	}
+	// in reverse video the default colors are swapped too
+	colorDecl(fgSpec, "fg", "color", bgDefault, st.Reverse)
+	colorDecl(bgSpec, "bg", "background-color", fgDefault, st.Reverse)
+
+	attr := func(on bool, class, decl string) {
+		switch {
+		case !on:
+		case h.opts.Classes:
+			classes = append(classes, class)
+		default:
+			css = append(css, decl)
+		}
+	}
+	attr(st.Bold, "pencil-bold", "font-weight: bold")
+	attr(st.Faint, "pencil-faint", "opacity: 0.7")
+	attr(st.Italic, "pencil-italic", "font-style: italic")
+	attr(st.Concealed, "pencil-concealed", "visibility: hidden")
+	switch {
+	case h.opts.Classes:
+		attr(st.Underline, "pencil-underline", "")
+		attr(st.CrossedOut, "pencil-crossed-out", "")
+	case st.Underline && st.CrossedOut:
+		css = append(css, "text-decoration: underline line-through")
+	case st.Underline:
+		css = append(css, "text-decoration: underline")
+	case st.CrossedOut:
+		css = append(css, "text-decoration: line-through")
+	}
+
+	if len(classes) == 0 && len(css) == 0 { // e.g. blink only
+		b.WriteString(text)
+		return
+	}
+	b.WriteString("<span")
+	if len(classes) > 0 {
+		fmt.Fprintf(b, ` class="%s"`, strings.Join(classes, " "))
+	}
+	if len(css) > 0 {
+		fmt.Fprintf(b, ` style="%s"`, strings.Join(css, "; "))
+	}
+	b.WriteString(">")
+	b.WriteString(text)
+	b.WriteString("</span>")
+}
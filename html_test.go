@@ -0,0 +1,100 @@
+package pencil
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+// testScheme has the basic colors 0-15 as #0000nn, where nn is the code,
+// with white on black as the default colors
+func testScheme() *ansirgb.Scheme {
+	s := &ansirgb.Scheme{
+		Name:       "test",
+		Foreground: color.RGBA{0xff, 0xff, 0xff, 0xff},
+		Background: color.RGBA{0, 0, 0, 0xff},
+	}
+	for i := range s.ANSI {
+		s.ANSI[i] = color.RGBA{0, 0, uint8(i), 0xff}
+	}
+	return s
+}
+
+func TestToHTML(t *testing.T) {
+	inline := HTMLOptions{Scheme: testScheme()}
+	classes := HTMLOptions{Scheme: testScheme(), Classes: true}
+	tests := []struct {
+		o       HTMLOptions
+		s, want string
+	}{
+		{inline, "plain", "plain"},
+		{inline, `<a href="x">&amp;</a>`, "&lt;a href=&#34;x&#34;&gt;&amp;amp;&lt;/a&gt;"},
+		{inline, "\x1b[1;31mred\x1b[0m", `<span style="color: #000001; font-weight: bold">red</span>`},
+		{inline, "\x1b[38;2;255;136;0;48;5;196mx\x1b[0m", `<span style="color: #ff8800; background-color: #ff0000">x</span>`},
+		{inline, "\x1b[4;9mx\x1b[0m", `<span style="text-decoration: underline line-through">x</span>`},
+		{inline, "\x1b[2;3;8mx\x1b[0m", `<span style="opacity: 0.7; font-style: italic; visibility: hidden">x</span>`},
+		{classes, "\x1b[1;31;44mx\x1b[0m", `<span class="pencil-fg-1 pencil-bg-4 pencil-bold">x</span>`},
+		{classes, "\x1b[4;9;38;5;202mx\x1b[0m", `<span class="pencil-underline pencil-crossed-out" style="color: #ff5f00">x</span>`},
+		// reverse video swaps the colors, and the default ones if unset
+		{inline, "\x1b[7;31mx\x1b[0m", `<span style="color: #000000; background-color: #000001">x</span>`},
+		{inline, "\x1b[7mx\x1b[0m", `<span style="color: #000000; background-color: #ffffff">x</span>`},
+		// a style without a visible effect writes no span
+		{inline, "\x1b[5mblink\x1b[0m", "blink"},
+		{inline, "\x1b]8;;https://example.com/?a=1&b=2\x1b\\\x1b[5mx\x1b[0m\x1b]8;;\x1b\\", `<a href="https://example.com/?a=1&amp;b=2">x</a>`},
+		{inline, "\x1b[1m<b>\x1b[0m", `<span style="font-weight: bold">&lt;b&gt;</span>`},
+	}
+	for _, tt := range tests {
+		if got := tt.o.ToHTML(tt.s); got != tt.want {
+			t.Errorf("ToHTML(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLStandalone(t *testing.T) {
+	o := HTMLOptions{Scheme: testScheme(), Standalone: true, Title: "<log>", Classes: true}
+	out := o.ToHTML("\x1b[1mx\x1b[0m")
+	for _, want := range []string{
+		"<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>&lt;log&gt;</title>\n<style>\n",
+		".pencil { color: #ffffff; background-color: #000000; padding: 1em; }\n",
+		".pencil-fg-15 { color: #00000f; }\n",
+		"</style>\n</head>\n<body>\n<pre class=\"pencil\"><span class=\"pencil-bold\">x</span></pre>\n</body>\n</html>\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ToHTML() = %q, want %q", out, want)
+		}
+	}
+
+	// an empty page is complete too
+	if out := o.ToHTML(""); !strings.HasSuffix(out, "<pre class=\"pencil\"></pre>\n</body>\n</html>\n") {
+		t.Errorf("ToHTML(\"\") = %q, want an empty page", out)
+	}
+}
+
+func TestHTMLWriterSplit(t *testing.T) {
+	// a sequence and a character split across writes are put back together
+	var b strings.Builder
+	w := NewHTMLWriter(&b, &HTMLOptions{Scheme: testScheme()})
+	for _, s := range []string{"a\x1b[3", "1mr\xc3", "\xa9d\x1b[0", "m b"} {
+		if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	w.Close()
+	if got, want := b.String(), `a<span style="color: #000001">r</span><span style="color: #000001">éd</span> b`; got != want {
+		t.Errorf("HTMLWriter wrote %q, want %q", got, want)
+	}
+
+	// an incomplete character is written by Close
+	b.Reset()
+	w = NewHTMLWriter(&b, &HTMLOptions{Scheme: testScheme()})
+	w.Write([]byte("x\xc3"))
+	if got := b.String(); got != "x" {
+		t.Errorf("HTMLWriter wrote %q before Close, want %q", got, "x")
+	}
+	w.Close()
+	if got := b.String(); got != "x\xc3" {
+		t.Errorf("HTMLWriter wrote %q after Close, want %q", got, "x\xc3")
+	}
+}
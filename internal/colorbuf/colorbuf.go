@@ -0,0 +1,63 @@
+// Package colorbuf formats the colored texts of the packages ansi8, ansi256
+// and rgb16b, in the buffer of the caller or in a pooled one.
+package colorbuf
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxBuffer limits the capacity of the buffers kept in buffers, so a single
+// huge text does not pin its memory
+const maxBuffer = 64 << 10
+
+// buffers holds the buffers used to format colored texts
+var buffers = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+func putBuffer(b *[]byte) {
+	if cap(*b) <= maxBuffer {
+		buffers.Put(b)
+	}
+}
+
+// Append appends the operands a formatted by fmt.Append (verb 0),
+// fmt.Appendf (verb 'f') or fmt.Appendln (verb 'l') to dst, between the SGR
+// sequence leading and the reset trailing
+func Append(dst []byte, leading, trailing string, verb byte, format string, a []interface{}) []byte {
+	dst = append(dst, leading...)
+	switch verb {
+	case 'f':
+		dst = fmt.Appendf(dst, format, a...)
+	case 'l':
+		dst = fmt.Appendln(dst, a...)
+	default:
+		dst = fmt.Append(dst, a...)
+	}
+	return append(dst, trailing...)
+}
+
+// Sprint returns the text of Append, formatted in a pooled buffer
+func Sprint(leading, trailing string, verb byte, format string, a []interface{}) string {
+	b := buffers.Get().(*[]byte)
+	*b = Append((*b)[:0], leading, trailing, verb, format, a)
+	s := string(*b)
+	putBuffer(b)
+	return s
+}
+
+// Fprint writes the text of Append to w in a single write, so the colors and
+// the text printed by concurrent goroutines do not interleave. The number of
+// bytes written includes the sequences.
+func Fprint(w io.Writer, leading, trailing string, verb byte, format string, a []interface{}) (int, error) {
+	b := buffers.Get().(*[]byte)
+	*b = Append((*b)[:0], leading, trailing, verb, format, a)
+	n, err := w.Write(*b)
+	putBuffer(b)
+	return n, err
+}
@@ -0,0 +1,90 @@
+package colorbuf_test
+
+import (
+	"image/color"
+	"io"
+	"testing"
+
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/ansi256"
+	"github.com/shyang107/pencil/ansi8"
+	"github.com/shyang107/pencil/rgb16b"
+)
+
+// printer is the Color of ansi8, ansi256 and rgb16b
+type printer interface {
+	Sprint(a ...interface{}) string
+	Sprintf(format string, a ...interface{}) string
+	Sprintln(a ...interface{}) string
+	AppendSprint(dst []byte, a ...interface{}) []byte
+	AppendSprintf(dst []byte, format string, a ...interface{}) []byte
+	AppendSprintln(dst []byte, a ...interface{}) []byte
+	EnableColor()
+	DisableColor()
+}
+
+func colors() map[string]printer {
+	return map[string]printer{
+		"ansi8":   ansi8.New(ansi8.FgRed, pencil.Bold, ansi8.BgBlue),
+		"ansi256": ansi256.New(208, pencil.Italic),
+		"rgb16b":  rgb16b.New(color.RGBA{0xff, 0x88, 0, 0xff}, pencil.Underline),
+	}
+}
+
+func TestAppendSprint(t *testing.T) {
+	for name, c := range colors() {
+		for _, enabled := range []bool{true, false} {
+			if enabled {
+				c.EnableColor()
+			} else {
+				c.DisableColor()
+			}
+			tests := []struct {
+				got, want string
+			}{
+				{string(c.AppendSprint(nil, "a", 1, 2)), c.Sprint("a", 1, 2)},
+				{string(c.AppendSprintf(nil, "%s=%03d", "a", 1)), c.Sprintf("%s=%03d", "a", 1)},
+				{string(c.AppendSprintln(nil, "a", 1)), c.Sprintln("a", 1)},
+				// the text is appended after the content of dst
+				{string(c.AppendSprint([]byte("x: "), "a")), "x: " + c.Sprint("a")},
+			}
+			for i, tt := range tests {
+				if tt.got != tt.want {
+					t.Errorf("%s (colors %v), call %d: Append* = %q, Sprint* = %q", name, enabled, i, tt.got, tt.want)
+				}
+			}
+		}
+	}
+}
+
+func benchColor() *ansi8.Color {
+	c := ansi8.New(ansi8.FgRed, pencil.Bold, ansi8.BgBlue)
+	c.EnableColor()
+	return c
+}
+
+func BenchmarkSprint(b *testing.B) {
+	c := benchColor()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Sprint("value", i)
+	}
+}
+
+func BenchmarkFprint(b *testing.B) {
+	c := benchColor()
+	w := pencil.NewWriterProfile(io.Discard, pencil.Profile{Mode: pencil.ModeRGB})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Fprint(w, "value", i)
+	}
+}
+
+func BenchmarkAppendSprint(b *testing.B) {
+	c := benchColor()
+	var buf []byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = c.AppendSprint(buf[:0], "value", i)
+	}
+}
@@ -0,0 +1,156 @@
+package pencil
+
+import (
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// linkEnd is the OSC 8 sequence ending a hyperlink
+const linkEnd = Escape + "]8;;" + Escape + `\`
+
+// Hyperlink is the target of a hyperlink written with the OSC 8 sequence,
+// ESC ] 8 ; params ; url ST, which modern terminals make clickable
+type Hyperlink struct {
+	URL string
+	// ID, if not empty, makes the terminal handle the parts of a link
+	// written apart, e.g. across lines, as a single link
+	ID string
+}
+
+// Link returns text as a hyperlink to url for the package output, or
+// "text (url)" if it does not support hyperlinks or NoColor is true
+func Link(url, text string) string {
+	return Hyperlink{URL: url}.Sprint(text)
+}
+
+// LinkFor is just like Link for an output of the profile p
+func LinkFor(p Profile, url, text string) string {
+	return Hyperlink{URL: url}.SprintFor(p, text)
+}
+
+// Sprint returns text as the hyperlink h for the package output; see Link
+func (h Hyperlink) Sprint(text string) string {
+	return h.SprintFor(defaultProfile(), text)
+}
+
+// SprintFor returns text as the hyperlink h for an output of the profile p:
+// the text, which may be styled, is wrapped in OSC 8 sequences if
+// p.Hyperlinks is true and p.NoColor is false, otherwise the URL follows it
+// in parentheses. An empty text stands for the URL itself.
+func (h Hyperlink) SprintFor(p Profile, text string) string {
+	url := sanitizeLink(h.URL, "")
+	if url == "" {
+		return text
+	}
+	if p.NoColor || !p.Hyperlinks {
+		switch {
+		case text == "":
+			return url
+		case Strip(text) == url:
+			return text
+		}
+		return text + " (" + url + ")"
+	}
+	if text == "" {
+		text = url
+	}
+	return h.Sequence() + text + linkEnd
+}
+
+// Sequence returns the OSC 8 sequence starting the hyperlink h; it is ended
+// by the same sequence with an empty URL
+func (h Hyperlink) Sequence() string {
+	params := ""
+	if id := sanitizeLink(h.ID, ":;"); id != "" {
+		params = "id=" + id
+	}
+	return Escape + "]8;" + params + ";" + sanitizeLink(h.URL, "") + Escape + `\`
+}
+
+// sanitizeLink returns s without the control characters, which would end
+// the OSC 8 sequence early, and the characters of drop
+func sanitizeLink(s, drop string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) || strings.ContainsRune(drop, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// exportable reports whether h may become a link of the HTML, Markdown or
+// BBCode output: only the http, https, mailto and file URLs do, the others,
+// e.g. javascript:, could run code in the reader of the document
+func (h *Hyperlink) exportable() bool {
+	if h == nil {
+		return false
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto", "file":
+		return true
+	}
+	return false
+}
+
+// parseLink returns the hyperlink started by the escape sequence seq, nil
+// if seq ends a hyperlink; ok is false if seq is not an OSC 8 sequence
+func parseLink(seq []byte) (h *Hyperlink, ok bool) {
+	s := string(seq)
+	if !strings.HasPrefix(s, Escape+"]8;") {
+		return nil, false
+	}
+	s = strings.TrimSuffix(strings.TrimSuffix(s[4:], "\a"), Escape+`\`)
+	i := strings.IndexByte(s, ';')
+	if i < 0 {
+		return nil, false
+	}
+	params, url := s[:i], s[i+1:]
+	if url == "" {
+		return nil, true
+	}
+	h = &Hyperlink{URL: url}
+	for _, kv := range strings.Split(params, ":") {
+		if strings.HasPrefix(kv, "id=") {
+			h.ID = kv[3:]
+		}
+	}
+	return h, true
+}
+
+// detectHyperlinks reports whether the terminal supports hyperlinks, from
+// the environment: FORCE_HYPERLINK decides if set, otherwise the terminals
+// known to support them are recognized
+func detectHyperlinks() bool {
+	if v, ok := os.LookupEnv("FORCE_HYPERLINK"); ok {
+		return v != "0" && strings.ToLower(v) != "false"
+	}
+	if os.Getenv("WT_SESSION") != "" || os.Getenv("KONSOLE_VERSION") != "" || os.Getenv("DOMTERM") != "" {
+		return true
+	}
+	if v, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper", "Tabby":
+		return true
+	}
+	term := strings.ToLower(os.Getenv("TERM"))
+	for _, t := range []string{"kitty", "alacritty", "foot", "wezterm", "ghostty"} {
+		if strings.Contains(term, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultProfile returns the profile of the package output, given by
+// NoColor and ColorProfile
+func defaultProfile() Profile {
+	return Profile{NoColor: NoColor, Mode: ColorProfile.Mode, Hyperlinks: ColorProfile.Hyperlinks}
+}
@@ -0,0 +1,126 @@
+package pencil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinkFor(t *testing.T) {
+	on := Profile{Mode: ModeRGB, Hyperlinks: true}
+	off := Profile{Mode: ModeRGB}
+	tests := []struct {
+		p         Profile
+		url, text string
+		want      string
+	}{
+		{on, "https://example.com", "docs", "\x1b]8;;https://example.com\x1b\\docs\x1b]8;;\x1b\\"},
+		{on, "https://example.com", "", "\x1b]8;;https://example.com\x1b\\https://example.com\x1b]8;;\x1b\\"},
+		{on, "https://example.com/\x1b\\x", "docs", "\x1b]8;;https://example.com/\\x\x1b\\docs\x1b]8;;\x1b\\"},
+		{on, "", "docs", "docs"},
+		{off, "https://example.com", "docs", "docs (https://example.com)"},
+		{off, "https://example.com", "", "https://example.com"},
+		{off, "https://example.com", "\x1b[1mhttps://example.com\x1b[0m", "\x1b[1mhttps://example.com\x1b[0m"},
+		{Profile{NoColor: true, Hyperlinks: true}, "https://example.com", "docs", "docs (https://example.com)"},
+	}
+	for _, tt := range tests {
+		if got := LinkFor(tt.p, tt.url, tt.text); got != tt.want {
+			t.Errorf("LinkFor(%+v, %q, %q) = %q, want %q", tt.p, tt.url, tt.text, got, tt.want)
+		}
+	}
+
+	if got := (Hyperlink{URL: "https://example.com", ID: "a:b;c"}).SprintFor(on, "x"); got != "\x1b]8;id=abc;https://example.com\x1b\\x\x1b]8;;\x1b\\" {
+		t.Errorf("SprintFor with an ID = %q", got)
+	}
+}
+
+func TestLinkNoColor(t *testing.T) {
+	defer func(nc bool, p Profile) { NoColor, ColorProfile = nc, p }(NoColor, ColorProfile)
+	NoColor, ColorProfile = true, Profile{Mode: ModeRGB, Hyperlinks: true}
+	if got, want := Link("https://example.com", "docs"), "docs (https://example.com)"; got != want {
+		t.Errorf("Link() with NoColor = %q, want %q", got, want)
+	}
+	NoColor = false
+	if got := Link("https://example.com", "docs"); !strings.HasPrefix(got, "\x1b]8;;") {
+		t.Errorf("Link() with hyperlinks = %q, want an OSC 8 sequence", got)
+	}
+}
+
+func TestStripLink(t *testing.T) {
+	tests := []struct {
+		s     string
+		want  string
+		width int
+	}{
+		{"\x1b]8;;https://example.com\x1b\\docs\x1b]8;;\x1b\\", "docs", 4},
+		{"\x1b]8;id=1;https://example.com\adocs\x1b]8;;\a!", "docs!", 5},
+		{"\x1b]8;;https://example.com\x1b\\\x1b[1m日本\x1b[0m\x1b]8;;\x1b\\", "日本", 4},
+		{"see \x1b]8;;https://exam", "see ", 4},
+	}
+	for _, tt := range tests {
+		if got := Strip(tt.s); got != tt.want {
+			t.Errorf("Strip(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+		if got := VisibleWidth(tt.s); got != tt.width {
+			t.Errorf("VisibleWidth(%q) = %d, want %d", tt.s, got, tt.width)
+		}
+	}
+}
+
+func TestParseSegmentsLink(t *testing.T) {
+	s := "a\x1b]8;id=x;https://example.com\x1b\\b\x1b[1mc\x1b[0md\x1b]8;;\x1b\\e"
+	link := &Hyperlink{URL: "https://example.com", ID: "x"}
+	want := []Segment{
+		{Text: "a"},
+		{Text: "b", Style: SegmentStyle{Link: link}},
+		{Text: "c", Style: SegmentStyle{Bold: true, Link: link}},
+		{Text: "d", Style: SegmentStyle{Link: link}},
+		{Text: "e"},
+	}
+	got := ParseSegments(s)
+	if len(got) != len(want) {
+		t.Fatalf("ParseSegments(%q) = %+v, want %+v", s, got, want)
+	}
+	for i := range want {
+		if got[i].Text != want[i].Text || !got[i].Style.equal(want[i].Style) {
+			t.Errorf("segment %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// the links written apart with the same ID are the same link
+	segs := ParseSegments("\x1b]8;id=x;https://example.com\x1b\\b\x1b]8;;\x1b\\\x1b]8;id=x;https://example.com\x1b\\c")
+	if len(segs) != 1 || segs[0].Text != "bc" {
+		t.Errorf("ParseSegments() of a link written twice = %+v, want one segment", segs)
+	}
+}
+
+func TestExportLinkSchemes(t *testing.T) {
+	tests := []struct {
+		url  string
+		safe bool
+	}{
+		{"https://example.com/a?b=c", true},
+		{"http://example.com", true},
+		{"HTTPS://example.com", true},
+		{"mailto:someone@example.com", true},
+		{"file:///tmp/report.txt", true},
+		{"javascript:alert(1)", false},
+		{"JavaScript:alert(1)", false},
+		{" javascript:alert(1)", false},
+		{"data:text/html,<script>alert(1)</script>", false},
+		{"vbscript:msgbox", false},
+		{"docs/index.html", false},
+	}
+	for _, tt := range tests {
+		s := "\x1b]8;;" + tt.url + "\x1b\\click\x1b]8;;\x1b\\"
+		html, md, bb := ToHTML(s), ToMarkdown(s), ToBBCode(s)
+		if tt.safe {
+			if !strings.Contains(html, "<a href=") || !strings.Contains(md, "](") || !strings.Contains(bb, "[url=") {
+				t.Errorf("%q: ToHTML() = %q, ToMarkdown() = %q, ToBBCode() = %q, want links", tt.url, html, md, bb)
+			}
+			continue
+		}
+		if html != "click" || md != "click" || bb != "click" {
+			t.Errorf("%q: ToHTML() = %q, ToMarkdown() = %q, ToBBCode() = %q, want plain text", tt.url, html, md, bb)
+		}
+	}
+}
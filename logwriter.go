@@ -0,0 +1,197 @@
+package pencil
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Style names of the timestamps and file:line references of the logs,
+// refined by "log."
+const (
+	StyleTimestamp = "timestamp"
+	StyleSource    = "source"
+)
+
+func init() {
+	DefaultTheme.
+		Set(StyleTimestamp, NewStyle(ANSIColor(8))).
+		Set(StyleSource, NewStyle(ANSIColor(6)))
+}
+
+// LevelRule detects a log level in a line by Pattern; the match is written in
+// the style Style of the theme, e.g. "log.level.error"
+type LevelRule struct {
+	Pattern *regexp.Regexp
+	Style   string
+}
+
+// DefaultLevelRules detects the usual level words, e.g. "ERROR", "[warn]",
+// "Debug:", in any case
+var DefaultLevelRules = []LevelRule{
+	{regexp.MustCompile(`(?i)\b(?:error|err|fatal|panic|crit(?:ical)?)\b`), "log.level.error"},
+	{regexp.MustCompile(`(?i)\b(?:warn|warning)\b`), "log.level.warning"},
+	{regexp.MustCompile(`(?i)\b(?:info|notice)\b`), "log.level.info"},
+	{regexp.MustCompile(`(?i)\b(?:debug|trace)\b`), "log.level.debug"},
+}
+
+var (
+	// DefaultTimestampPattern matches the timestamps of the log package
+	// ("2006/01/02 15:04:05.000000"), RFC 3339 and plain times
+	DefaultTimestampPattern = regexp.MustCompile(
+		`\d{4}[/-]\d{2}[/-]\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?|\b\d{2}:\d{2}:\d{2}(?:\.\d+)?\b`)
+	// DefaultSourcePattern matches file:line references, e.g. "main.go:42"
+	DefaultSourcePattern = regexp.MustCompile(`[\w./-]+\.\w+:\d+`)
+)
+
+// LogWriter is an io.Writer colorizing log lines, to be set as the output of
+// the standard log package or of other io.Writer based loggers:
+//
+//	log.SetOutput(pencil.NewLogWriter(os.Stderr))
+//
+// In each line the first level detected by Levels, the timestamps and the
+// file:line references are written in the styles of Theme ("log.level.*",
+// "log.timestamp" and "log.source"). Lines already containing escape
+// sequences are written as they are, and everything passes through
+// untouched if the profile disables colors.
+type LogWriter struct {
+	Profile   Profile
+	Levels    []LevelRule
+	Timestamp *regexp.Regexp // nil disables the timestamps highlighting
+	Source    *regexp.Regexp // nil disables the file:line highlighting
+	Theme     *Theme         // CurrentTheme() if nil
+
+	w       io.Writer
+	pending []byte // an incomplete line
+	mu      sync.Mutex
+}
+
+// NewLogWriter returns a LogWriter writing to w with the default rules and
+// the profile detected from w (see NewWriter)
+func NewLogWriter(w io.Writer) *LogWriter {
+	return &LogWriter{
+		Profile:   NewWriter(w).Profile,
+		Levels:    DefaultLevelRules,
+		Timestamp: DefaultTimestampPattern,
+		Source:    DefaultSourcePattern,
+		w:         w,
+	}
+}
+
+// Write implements io.Writer. Complete lines are written at once; the rest
+// is held back until its newline or Flush. If the underlying writer fails,
+// Write returns 0 and the lines held back before p are kept.
+func (l *LogWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.Profile.NoColor && len(l.pending) == 0 {
+		return l.w.Write(p)
+	}
+
+	prev := l.pending
+	data := append(prev[:len(prev):len(prev)], p...)
+	l.pending = nil
+	i := bytes.LastIndexByte(data, '\n')
+	if i < 0 {
+		l.pending = data
+		return len(p), nil
+	}
+	if i+1 < len(data) {
+		l.pending = append([]byte(nil), data[i+1:]...)
+	}
+
+	var buf bytes.Buffer
+	for _, line := range bytes.SplitAfter(data[:i+1], []byte("\n")) {
+		if len(line) > 0 {
+			buf.WriteString(l.colorize(string(line[:len(line)-1])))
+			buf.WriteByte('\n')
+		}
+	}
+	if _, err := l.w.Write(buf.Bytes()); err != nil {
+		// nothing of p is taken, so writing p again does not repeat it
+		l.pending = prev
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes the incomplete line held back by Write; the line is kept if
+// the underlying writer fails
+func (l *LogWriter) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) == 0 {
+		return nil
+	}
+	if _, err := io.WriteString(l.w, l.colorize(string(l.pending))); err != nil {
+		return err
+	}
+	l.pending = nil
+	return nil
+}
+
+// ColorProfile implements Profiler
+func (l *LogWriter) ColorProfile() Profile {
+	return l.Profile
+}
+
+// logSpan is a part of a line to write in a style
+type logSpan struct {
+	start, end int
+	style      string
+}
+
+// colorize returns line with its level, timestamps and sources styled
+func (l *LogWriter) colorize(line string) string {
+	if l.Profile.NoColor || strings.IndexByte(line, 0x1b) >= 0 {
+		return line
+	}
+
+	var spans []logSpan
+	level := logSpan{start: -1}
+	for _, r := range l.Levels {
+		if loc := r.Pattern.FindStringIndex(line); loc != nil && (level.start < 0 || loc[0] < level.start) {
+			level = logSpan{loc[0], loc[1], r.Style}
+		}
+	}
+	if level.start >= 0 {
+		spans = append(spans, level)
+	}
+	for _, p := range []struct {
+		re    *regexp.Regexp
+		style string
+	}{{l.Timestamp, "log.timestamp"}, {l.Source, "log.source"}} {
+		if p.re == nil {
+			continue
+		}
+		for _, loc := range p.re.FindAllStringIndex(line, -1) {
+			spans = append(spans, logSpan{loc[0], loc[1], p.style})
+		}
+	}
+	if len(spans) == 0 {
+		return line
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	theme := l.Theme
+	if theme == nil {
+		theme = CurrentTheme()
+	}
+	var b bytes.Buffer
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			continue // overlaps the previous span
+		}
+		b.WriteString(line[last:s.start])
+		b.WriteString(theme.Style(s.style).SprintFor(l.Profile, line[s.start:s.end]))
+		last = s.end
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package pencil
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testLogWriter(w *strings.Builder) *LogWriter {
+	l := NewLogWriter(w)
+	l.Profile = Profile{Mode: ModeANSI8}
+	l.Theme = NewTheme("test").
+		Set("log.level.error", NewStyle(ANSIColor(1))).
+		Set("log.level.warning", NewStyle(ANSIColor(3))).
+		Set("log.level.info", NewStyle(ANSIColor(2))).
+		Set("log.timestamp", NewStyle(ANSIColor(8))).
+		Set("log.source", NewStyle(ANSIColor(6)))
+	return l
+}
+
+func TestLogWriter(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{
+			"2024/01/02 15:04:05 main.go:42: info: retry after error",
+			"\x1b[90m2024/01/02 15:04:05\x1b[0m \x1b[36mmain.go:42\x1b[0m: \x1b[32minfo\x1b[0m: retry after error",
+		},
+		// the first level of the line wins, whatever the order of the rules
+		{"[WARN] ERROR ahead", "[\x1b[33mWARN\x1b[0m] ERROR ahead"},
+		{"Err: disk full, warning sent", "\x1b[31mErr\x1b[0m: disk full, warning sent"},
+		{"information is not a level", "information is not a level"},
+		{
+			"2024-01-02T15:04:05.123Z took 10:11:12.5 in pkg/db/conn.go:7",
+			"\x1b[90m2024-01-02T15:04:05.123Z\x1b[0m took \x1b[90m10:11:12.5\x1b[0m in \x1b[36mpkg/db/conn.go:7\x1b[0m",
+		},
+		// lines with escapes are left alone
+		{"\x1b[1mERROR\x1b[0m main.go:1", "\x1b[1mERROR\x1b[0m main.go:1"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		l := testLogWriter(&b)
+		if n, err := l.Write([]byte(tt.line + "\n")); n != len(tt.line)+1 || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", tt.line, n, err)
+		}
+		if got := b.String(); got != tt.want+"\n" {
+			t.Errorf("LogWriter wrote %q, want %q", got, tt.want+"\n")
+		}
+	}
+
+	// the levels can be replaced, as can the patterns be disabled
+	var b strings.Builder
+	l := testLogWriter(&b)
+	l.Levels = []LevelRule{{DefaultLevelRules[1].Pattern, "log.level.error"}}
+	l.Timestamp, l.Source = nil, nil
+	l.Write([]byte("15:04:05 a.go:1 warn error\n"))
+	if got, want := b.String(), "15:04:05 a.go:1 \x1b[31mwarn\x1b[0m error\n"; got != want {
+		t.Errorf("LogWriter wrote %q, want %q", got, want)
+	}
+}
+
+func TestLogWriterPartialLines(t *testing.T) {
+	var b strings.Builder
+	l := testLogWriter(&b)
+	l.Write([]byte("ERROR a"))
+	if b.Len() != 0 {
+		t.Fatalf("LogWriter wrote %q of a partial line", b.String())
+	}
+	l.Write([]byte(" b\nINFO c"))
+	if got, want := b.String(), "\x1b[31mERROR\x1b[0m a b\n"; got != want {
+		t.Errorf("LogWriter wrote %q, want %q", got, want)
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "\x1b[31mERROR\x1b[0m a b\n\x1b[32mINFO\x1b[0m c"; got != want {
+		t.Errorf("LogWriter wrote %q after Flush, want %q", got, want)
+	}
+	if err := l.Flush(); err != nil || b.Len() != len("\x1b[31mERROR\x1b[0m a b\n\x1b[32mINFO\x1b[0m c") {
+		t.Errorf("a second Flush wrote %q, %v", b.String(), err)
+	}
+
+	// without colors everything passes through at once
+	b.Reset()
+	l = testLogWriter(&b)
+	l.Profile = Profile{NoColor: true}
+	l.Write([]byte("ERROR a"))
+	if got := b.String(); got != "ERROR a" {
+		t.Errorf("LogWriter without colors wrote %q, want %q", got, "ERROR a")
+	}
+}
+
+// failingWriter fails the first fails writes
+type failingWriter struct {
+	b     strings.Builder
+	fails int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.fails > 0 {
+		w.fails--
+		return 0, errors.New("disk full")
+	}
+	return w.b.Write(p)
+}
+
+func (w *failingWriter) String() string {
+	return w.b.String()
+}
+
+func TestLogWriterError(t *testing.T) {
+	w := &failingWriter{fails: 1}
+	l := NewLogWriter(w)
+	l.Profile = Profile{Mode: ModeANSI8}
+	l.Theme = NewTheme("empty")
+
+	l.Write([]byte("a"))
+	// neither the failed p nor the line held back before it are lost: p
+	// can be written again
+	if n, err := l.Write([]byte("b\nc")); n != 0 || err == nil {
+		t.Fatalf("Write() = %d, %v, want 0 and the error", n, err)
+	}
+	if n, err := l.Write([]byte("b\nc")); n != 3 || err != nil {
+		t.Fatalf("Write() = %d, %v, want 3", n, err)
+	}
+	if got := w.String(); got != "ab\n" {
+		t.Errorf("LogWriter wrote %q, want %q", got, "ab\n")
+	}
+
+	// the line of a failed Flush is kept
+	w.fails = 1
+	if err := l.Flush(); err == nil {
+		t.Fatal("Flush() = nil, want the error")
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.String(); got != "ab\nc" {
+		t.Errorf("LogWriter wrote %q, want %q", got, "ab\nc")
+	}
+}
@@ -3,17 +3,21 @@ package pencil
 import (
 	"io"
 	"os"
-
-	isatty "github.com/mattn/go-isatty"
 )
 
 var (
+	// ColorProfile is the color profile of os.Stdout detected at start-up by
+	// DetectProfile(), which documents the precedence of the environment
+	// variables NO_COLOR, FORCE_COLOR, CLICOLOR_FORCE, CLICOLOR and TERM. Use
+	// Reload() to detect it again.
+	ColorProfile = DetectProfile(os.Stdout)
+
 	// NoColor defines if the output is colorized or not. It's dynamically set to
-	// false or true based on the stdout's file descriptor referring to a terminal
-	// or not. This is a global option and affects all colors. For more control
-	// over each color block use the methods DisableColor() individually.
-	NoColor = os.Getenv("TERM") == "dumb" ||
-		(!isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd())) //&& Glog.Printer.IsTerminal
+	// false or true based on the environment and the stdout's file descriptor
+	// referring to a terminal or not (see ColorProfile). This is a global option
+	// and affects all colors. For more control over each color block use the
+	// methods DisableColor() individually.
+	NoColor = ColorProfile.NoColor
 
 	// Output defines the standard output of the print functions. By default
 	// os.Stdout is used.
@@ -88,13 +92,17 @@ const (
 // }
 
 // NewColorableStdout return new instance of Writer which handle escape sequence for stdout.
+// On a Windows console without virtual terminal processing, the colors are
+// emulated by console attributes (see NewColorable).
 func NewColorableStdout() io.Writer {
-	return os.Stdout
+	return NewColorable(os.Stdout)
 }
 
 // NewColorableStderr return new instance of Writer which handle escape sequence for stderr.
+// On a Windows console without virtual terminal processing, the colors are
+// emulated by console attributes (see NewColorable).
 func NewColorableStderr() io.Writer {
-	return os.Stderr
+	return NewColorable(os.Stderr)
 }
 
 // BoolPtr return &{bool}
@@ -0,0 +1,615 @@
+package pencil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Style names of PrettyJSON, PrettyYAML and Dump, refined by "json.",
+// "yaml." and "dump."
+const (
+	StyleKey         = "key"
+	StyleString      = "string"
+	StyleNumber      = "number"
+	StyleBool        = "bool"
+	StyleNull        = "null"
+	StylePunctuation = "punctuation"
+)
+
+func init() {
+	DefaultTheme.
+		Set(StyleKey, NewStyle(ANSIColor(4))).
+		Set(StyleString, NewStyle(ANSIColor(2))).
+		Set(StyleNumber, NewStyle(ANSIColor(5))).
+		Set(StyleBool, NewStyle(ANSIColor(3))).
+		Set(StyleNull, NewStyle(ANSIColor(8))).
+		Set(StylePunctuation, NewStyle(ANSIColor(8)))
+}
+
+// PrettyOptions are the options of PrettyJSON, PrettyYAML and Dump
+type PrettyOptions struct {
+	// Indent is the indentation of each level, two spaces if empty; it must
+	// be spaces for PrettyYAML
+	Indent string
+	// MaxDepth is the depth below which the objects, arrays and values are
+	// elided as "{…}"; 0 means no limit
+	MaxDepth int
+	// Theme gives the styles; CurrentTheme() if nil
+	Theme *Theme
+	// Profile is the color profile of the output; the global NoColor and the
+	// color mode of the terminal if nil
+	Profile *Profile
+}
+
+// DefaultPrettyOptions are the options of PrettyJSON, PrettyYAML and Dump
+var DefaultPrettyOptions = PrettyOptions{Indent: "  "}
+
+// PrettyJSON returns the JSON data indented and colored: the keys, strings,
+// numbers, booleans, nulls and punctuation take the styles "json.key",
+// "json.string" ... of the theme (see Theme.Lookup). The order of the keys
+// is kept; a stream of several values is rendered value by value.
+func PrettyJSON(data []byte) (string, error) {
+	return DefaultPrettyOptions.PrettyJSON(data)
+}
+
+// PrettyYAML returns the YAML data reindented in block style and colored
+// with the styles "yaml.key", "yaml.string" ... of the theme; the scalars
+// take the style of their type. The order of the keys, the quoting of the
+// strings, the anchors, aliases and tags are kept, the comments are
+// dropped. The documents of a stream are separated by "---".
+func PrettyYAML(data []byte) (string, error) {
+	return DefaultPrettyOptions.PrettyYAML(data)
+}
+
+// Dump returns a Go-like, indented and colored representation of v, such as
+// fmt's "%#v" with one field or element per line. The styles are "dump.key",
+// "dump.string" ... of the theme. Pointers seen again on the path from the
+// root, e.g. of a linked list pointing back to its head, are written as
+// "<cycle>" instead of being followed.
+func Dump(v interface{}) string {
+	return DefaultPrettyOptions.Dump(v)
+}
+
+// PrettyJSON is PrettyJSON with the options o
+func (o PrettyOptions) PrettyJSON(data []byte) (string, error) {
+	p := o.printer("json")
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if err := p.jsonValue(dec, tok, 0); err != nil {
+			return "", err
+		}
+		p.buf.WriteByte('\n')
+	}
+	return p.buf.String(), nil
+}
+
+// PrettyYAML is PrettyYAML with the options o
+func (o PrettyOptions) PrettyYAML(data []byte) (string, error) {
+	p := o.printer("yaml")
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for n := 0; ; n++ {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if n > 0 {
+			p.style(StylePunctuation, "---")
+			p.buf.WriteByte('\n')
+		}
+		if len(doc.Content) > 0 {
+			root := doc.Content[0]
+			if props := yamlProps(root); props != "" {
+				p.style(StylePunctuation, props)
+				if p.yamlBlock(root, 0) {
+					p.newline(0)
+				} else {
+					p.buf.WriteByte(' ')
+				}
+			}
+			p.yamlNode(root, 0)
+		}
+		p.buf.WriteByte('\n')
+	}
+	return p.buf.String(), nil
+}
+
+// Dump is Dump with the options o
+func (o PrettyOptions) Dump(v interface{}) string {
+	p := o.printer("dump")
+	p.visiting = make(map[visit]bool)
+	p.value(reflect.ValueOf(v), 0)
+	return p.buf.String()
+}
+
+func (o PrettyOptions) printer(context string) *printer {
+	p := &printer{opts: o, context: context, theme: o.Theme}
+	if p.opts.Indent == "" {
+		p.opts.Indent = "  "
+	}
+	if p.theme == nil {
+		p.theme = CurrentTheme()
+	}
+	if o.Profile != nil {
+		p.profile = *o.Profile
+	} else {
+		p.profile = defaultProfile()
+	}
+	return p
+}
+
+// visit is a pointer on the path of Dump, to detect cycles
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+type printer struct {
+	opts     PrettyOptions
+	context  string // prefix of the style names, "json" or "dump"
+	theme    *Theme
+	profile  Profile
+	buf      bytes.Buffer
+	visiting map[visit]bool
+}
+
+// style writes s in the style context.name
+func (p *printer) style(name, s string) {
+	p.buf.WriteString(p.theme.Style(p.context+"."+name).SprintFor(p.profile, s))
+}
+
+func (p *printer) newline(depth int) {
+	p.buf.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		p.buf.WriteString(p.opts.Indent)
+	}
+}
+
+// elided reports whether the containers at depth are elided by MaxDepth
+func (p *printer) elided(depth int) bool {
+	return p.opts.MaxDepth > 0 && depth >= p.opts.MaxDepth
+}
+
+//---------------------------------------------------------
+// JSON
+
+// jsonValue writes the JSON value starting with tok, the token just read
+// from dec
+func (p *printer) jsonValue(dec *json.Decoder, tok json.Token, depth int) error {
+	switch t := tok.(type) {
+	case json.Delim:
+		open, close := string(t), "}"
+		if t == '[' {
+			close = "]"
+		}
+		if !dec.More() {
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+			p.style(StylePunctuation, open+close)
+			return nil
+		}
+		if p.elided(depth + 1) {
+			p.style(StylePunctuation, open)
+			p.style(StyleMuted, "…")
+			p.style(StylePunctuation, close)
+			return skipJSON(dec)
+		}
+		p.style(StylePunctuation, open)
+		for n := 0; dec.More(); n++ {
+			if n > 0 {
+				p.style(StylePunctuation, ",")
+			}
+			p.newline(depth + 1)
+			if t == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				p.style(StyleKey, jsonQuote(key.(string)))
+				p.style(StylePunctuation, ":")
+				p.buf.WriteByte(' ')
+			}
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if err := p.jsonValue(dec, tok, depth+1); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		p.newline(depth)
+		p.style(StylePunctuation, close)
+	case string:
+		p.style(StyleString, jsonQuote(t))
+	case json.Number:
+		p.style(StyleNumber, t.String())
+	case bool:
+		p.style(StyleBool, strconv.FormatBool(t))
+	case nil:
+		p.style(StyleNull, "null")
+	}
+	return nil
+}
+
+// skipJSON skips the rest of the object or array just opened
+func skipJSON(dec *json.Decoder) error {
+	for depth := 1; depth > 0; {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+	}
+	return nil
+}
+
+// jsonQuote returns s as a JSON string, without escaping HTML characters
+func jsonQuote(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+//---------------------------------------------------------
+// YAML
+
+// yamlBlock reports whether the node n is written as a block of entries
+// indented to depth: a mapping or sequence neither empty nor elided
+func (p *printer) yamlBlock(n *yaml.Node, depth int) bool {
+	return (n.Kind == yaml.MappingNode || n.Kind == yaml.SequenceNode) &&
+		len(n.Content) > 0 && !p.elided(depth+1)
+}
+
+// yamlProps returns the anchor and the explicit tag of the node n, e.g.
+// "&base !!map"
+func yamlProps(n *yaml.Node) string {
+	var props []string
+	if n.Anchor != "" {
+		props = append(props, "&"+n.Anchor)
+	}
+	if n.Style&yaml.TaggedStyle != 0 {
+		props = append(props, n.Tag)
+	}
+	return strings.Join(props, " ")
+}
+
+// yamlValue writes the node n following the indicator ":", "-" or "?"; the
+// entries of a block start on the next line, indented to depth, unless
+// compact is true: a block without properties then starts on the same line,
+// e.g. "- name: x"
+func (p *printer) yamlValue(n *yaml.Node, depth int, compact bool) {
+	props := yamlProps(n)
+	if props != "" {
+		p.buf.WriteByte(' ')
+		p.style(StylePunctuation, props)
+	}
+	switch {
+	case !p.yamlBlock(n, depth):
+		p.buf.WriteByte(' ')
+	case compact && props == "" && len(p.opts.Indent) > 1:
+		p.buf.WriteString(p.opts.Indent[1:])
+	default:
+		p.newline(depth)
+	}
+	p.yamlNode(n, depth)
+}
+
+// yamlNode writes the node n without its properties; the entries of a
+// mapping or sequence are indented to depth
+func (p *printer) yamlNode(n *yaml.Node, depth int) {
+	switch n.Kind {
+	case yaml.AliasNode:
+		p.style(StylePunctuation, "*"+n.Value)
+	case yaml.ScalarNode:
+		p.yamlScalar(n, yamlStyle(n.ShortTag()), depth)
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, close := "{", "}"
+		if n.Kind == yaml.SequenceNode {
+			open, close = "[", "]"
+		}
+		if len(n.Content) == 0 {
+			p.style(StylePunctuation, open+close)
+			return
+		}
+		if p.elided(depth + 1) {
+			p.style(StylePunctuation, open)
+			p.style(StyleMuted, "…")
+			p.style(StylePunctuation, close)
+			return
+		}
+		if n.Kind == yaml.SequenceNode {
+			for i, item := range n.Content {
+				if i > 0 {
+					p.newline(depth)
+				}
+				p.style(StylePunctuation, "-")
+				p.yamlValue(item, depth+1, true)
+			}
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if i > 0 {
+				p.newline(depth)
+			}
+			p.yamlKey(n.Content[i], depth)
+			p.style(StylePunctuation, ":")
+			p.yamlValue(n.Content[i+1], depth+1, false)
+		}
+	}
+}
+
+// yamlKey writes the key of a mapping entry; a key other than a scalar or
+// an alias is written as a complex key, "? key"
+func (p *printer) yamlKey(key *yaml.Node, depth int) {
+	if key.Kind != yaml.ScalarNode && key.Kind != yaml.AliasNode {
+		p.style(StylePunctuation, "?")
+		p.yamlValue(key, depth+1, true)
+		p.newline(depth)
+		return
+	}
+	if props := yamlProps(key); props != "" {
+		p.style(StylePunctuation, props)
+		p.buf.WriteByte(' ')
+	}
+	if key.Kind == yaml.AliasNode {
+		// the colon would be taken as part of the alias name
+		p.style(StylePunctuation, "*"+key.Value)
+		p.buf.WriteByte(' ')
+		return
+	}
+	p.yamlScalar(key, StyleKey, -1)
+}
+
+// yamlScalar writes the scalar n in the style name, quoted as in the input;
+// a literal or folded string is written as a literal block indented to
+// depth, or quoted if depth is negative, for a key
+func (p *printer) yamlScalar(n *yaml.Node, name string, depth int) {
+	v := n.Value
+	block := n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 && depth >= 0 &&
+		v != "" && v[0] != ' ' && v[0] != '\n'
+	switch {
+	case block:
+		text := strings.TrimRight(v, "\n")
+		switch {
+		case text == v:
+			p.style(StylePunctuation, "|-")
+		case text+"\n" == v:
+			p.style(StylePunctuation, "|")
+		default:
+			p.style(StylePunctuation, "|+")
+		}
+		for _, line := range strings.Split(text, "\n") {
+			if line == "" {
+				p.buf.WriteByte('\n')
+				continue
+			}
+			p.newline(depth)
+			p.style(name, line)
+		}
+		// the final newlines kept by "|+" but the last one
+		for i := len(text) + 1; i < len(v); i++ {
+			p.buf.WriteByte('\n')
+		}
+	case n.Style&yaml.DoubleQuotedStyle != 0 || strings.ContainsAny(v, "\n\r") ||
+		n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		p.style(name, jsonQuote(v))
+	case n.Style&yaml.SingleQuotedStyle != 0:
+		p.style(name, "'"+strings.ReplaceAll(v, "'", "''")+"'")
+	case v == "" && n.ShortTag() == "!!null":
+		p.style(name, "null")
+	case v == "":
+		p.style(name, `""`)
+	default:
+		p.style(name, v)
+	}
+}
+
+// yamlStyle returns the style name of the scalars of the tag
+func yamlStyle(tag string) string {
+	switch tag {
+	case "!!int", "!!float":
+		return StyleNumber
+	case "!!bool":
+		return StyleBool
+	case "!!null":
+		return StyleNull
+	default:
+		return StyleString
+	}
+}
+
+//---------------------------------------------------------
+// Go values
+
+var (
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// value writes the Go value v
+func (p *printer) value(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		p.style(StyleNull, "nil")
+		return
+	}
+
+	if v.Kind() == reflect.Interface {
+		p.value(v.Elem(), depth)
+		return
+	}
+
+	t := v.Type()
+	if v.CanInterface() && (t.Implements(errorType) || t.Implements(stringerType)) &&
+		!(v.Kind() == reflect.Ptr && v.IsNil()) {
+		var s string
+		if err, ok := v.Interface().(error); ok {
+			s = err.Error()
+		} else {
+			s = v.Interface().(fmt.Stringer).String()
+		}
+		p.buf.WriteString(t.String())
+		p.style(StylePunctuation, "(")
+		p.style(StyleString, strconv.Quote(s))
+		p.style(StylePunctuation, ")")
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		p.style(StyleBool, strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		p.style(StyleNumber, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		p.style(StyleNumber, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		p.style(StyleNumber, strconv.FormatFloat(v.Float(), 'g', -1, t.Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		p.style(StyleNumber, strconv.FormatComplex(v.Complex(), 'g', -1, t.Bits()))
+	case reflect.String:
+		p.style(StyleString, strconv.Quote(v.String()))
+	case reflect.Ptr:
+		if v.IsNil() {
+			p.style(StyleNull, "nil")
+			return
+		}
+		if p.enter(v) {
+			defer p.leave(v)
+			p.style(StylePunctuation, "&")
+			p.value(v.Elem(), depth)
+		}
+	case reflect.Struct:
+		p.buf.WriteString(t.String())
+		if t.NumField() == 0 {
+			p.style(StylePunctuation, "{}")
+			return
+		}
+		p.container(depth, "{", "}", t.NumField(), func(i int) {
+			p.style(StyleKey, t.Field(i).Name)
+			p.style(StylePunctuation, ":")
+			p.buf.WriteByte(' ')
+			p.value(v.Field(i), depth+1)
+		})
+	case reflect.Map:
+		p.buf.WriteString(t.String())
+		if v.IsNil() {
+			p.style(StylePunctuation, "(")
+			p.style(StyleNull, "nil")
+			p.style(StylePunctuation, ")")
+			return
+		}
+		if !p.enter(v) {
+			return
+		}
+		defer p.leave(v)
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		p.container(depth, "{", "}", len(keys), func(i int) {
+			p.value(keys[i], depth+1)
+			p.style(StylePunctuation, ":")
+			p.buf.WriteByte(' ')
+			p.value(v.MapIndex(keys[i]), depth+1)
+		})
+	case reflect.Slice, reflect.Array:
+		p.buf.WriteString(t.String())
+		if v.Kind() == reflect.Slice {
+			if v.IsNil() {
+				p.style(StylePunctuation, "(")
+				p.style(StyleNull, "nil")
+				p.style(StylePunctuation, ")")
+				return
+			}
+			if t.Elem().Kind() == reflect.Uint8 {
+				p.style(StylePunctuation, "(")
+				p.style(StyleString, strconv.Quote(string(v.Bytes())))
+				p.style(StylePunctuation, ")")
+				return
+			}
+			if !p.enter(v) {
+				return
+			}
+			defer p.leave(v)
+		}
+		p.container(depth, "{", "}", v.Len(), func(i int) {
+			p.value(v.Index(i), depth+1)
+		})
+	default: // Chan, Func, UnsafePointer
+		p.buf.WriteString(t.String())
+		p.style(StylePunctuation, "(")
+		if v.IsNil() {
+			p.style(StyleNull, "nil")
+		} else {
+			p.style(StyleNumber, fmt.Sprintf("%#x", v.Pointer()))
+		}
+		p.style(StylePunctuation, ")")
+	}
+}
+
+// container writes n elements between open and close, one per line, each
+// written by elem
+func (p *printer) container(depth int, open, close string, n int, elem func(i int)) {
+	if n == 0 {
+		p.style(StylePunctuation, open+close)
+		return
+	}
+	if p.elided(depth + 1) {
+		p.style(StylePunctuation, open)
+		p.style(StyleMuted, "…")
+		p.style(StylePunctuation, close)
+		return
+	}
+	p.style(StylePunctuation, open)
+	for i := 0; i < n; i++ {
+		p.newline(depth + 1)
+		elem(i)
+		p.style(StylePunctuation, ",")
+	}
+	p.newline(depth)
+	p.style(StylePunctuation, close)
+}
+
+// enter marks the pointer, map or slice v as being written; it returns false
+// and writes "<cycle>" if it already is
+func (p *printer) enter(v reflect.Value) bool {
+	k := visit{v.Pointer(), v.Type()}
+	if p.visiting[k] {
+		p.style(StyleMuted, "<cycle>")
+		return false
+	}
+	p.visiting[k] = true
+	return true
+}
+
+func (p *printer) leave(v reflect.Value) {
+	delete(p.visiting, visit{v.Pointer(), v.Type()})
+}
@@ -0,0 +1,192 @@
+package pencil
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const prettyYAMLSource = `# dropped comment
+name: app
+version: 1.2
+enabled: true
+none:
+empty: ""
+quoted: "a: b"
+single: 'it''s'
+tagged: !!str 123
+base: &base
+  host: localhost
+  ports: [80, 443]
+prod:
+  <<: *base
+  host: example.com
+list:
+- a
+- b: 1
+  c: 2
+- - x
+  - y
+- {}
+text: |
+  line one
+
+  line three
+folded: >
+  folded
+  text
+keep: |+
+  kept
+
+label: &label x
+*label : alias key
+---
+- second
+`
+
+func TestPrettyYAMLRoundTrip(t *testing.T) {
+	for _, indent := range []string{"", "    "} {
+		o := PrettyOptions{Indent: indent, Profile: &Profile{NoColor: true}}
+		out, err := o.PrettyYAML([]byte(prettyYAMLSource))
+		if err != nil {
+			t.Fatalf("PrettyYAML() error = %v", err)
+		}
+		want, got := yamlDocuments(t, prettyYAMLSource), yamlDocuments(t, out)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("indent %q: PrettyYAML() =\n%s\ndecodes to %v, want %v", indent, out, got, want)
+		}
+		if strings.Contains(out, "comment") {
+			t.Errorf("indent %q: PrettyYAML() kept the comment:\n%s", indent, out)
+		}
+	}
+}
+
+// yamlDocuments returns the documents of the YAML stream src
+func yamlDocuments(t *testing.T, src string) []interface{} {
+	var docs []interface{}
+	dec := yaml.NewDecoder(strings.NewReader(src))
+	for {
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			if err != io.EOF {
+				t.Fatalf("decoding %q: %v", src, err)
+			}
+			return docs
+		}
+		docs = append(docs, v)
+	}
+}
+
+func TestPrettyYAML(t *testing.T) {
+	p := Profile{Mode: ModeANSI8}
+	th := NewTheme("test").
+		Set("yaml.key", NewStyle(ANSIColor(4))).
+		Set(StyleString, NewStyle(ANSIColor(2))).
+		Set(StyleNumber, NewStyle(ANSIColor(5))).
+		Set(StylePunctuation, Style{})
+	o := PrettyOptions{Theme: th, Profile: &p, MaxDepth: 3}
+	out, err := o.PrettyYAML([]byte("a: 1\nb:\n  c: x\n  d:\n    e: y\n"))
+	if err != nil {
+		t.Fatalf("PrettyYAML() error = %v", err)
+	}
+	key := func(s string) string { return th.Style("yaml.key").SprintFor(p, s) }
+	want := key("a") + ": " + th.Style("yaml.number").SprintFor(p, "1") + "\n" +
+		key("b") + ":\n  " +
+		key("c") + ": " + th.Style("yaml.string").SprintFor(p, "x") + "\n  " +
+		key("d") + ": {" + th.Style("yaml.muted").SprintFor(p, "…") + "}\n"
+	if out != want {
+		t.Errorf("PrettyYAML() = %q, want %q", out, want)
+	}
+}
+
+func TestPrettyJSON(t *testing.T) {
+	p := Profile{NoColor: true}
+	o := PrettyOptions{Profile: &p}
+	tests := []struct {
+		maxDepth int
+		in, want string
+	}{
+		// the keys keep the order of the input
+		{0, `{"b":1,"a":[true,null,"x<y"],"c":{}}`, "{\n  \"b\": 1,\n  \"a\": [\n    true,\n    null,\n    \"x<y\"\n  ],\n  \"c\": {}\n}\n"},
+		{2, `{"b":1,"a":[1,2],"c":{"d":{}}}`, "{\n  \"b\": 1,\n  \"a\": […],\n  \"c\": {…}\n}\n"},
+		{3, `{"a":[1,{"b":2}]}`, "{\n  \"a\": [\n    1,\n    {…}\n  ]\n}\n"},
+		{1, `[[]] 1.50 []`, "[…]\n1.50\n[]\n"},
+	}
+	for _, tt := range tests {
+		o.MaxDepth = tt.maxDepth
+		got, err := o.PrettyJSON([]byte(tt.in))
+		if err != nil || got != tt.want {
+			t.Errorf("PrettyJSON(%s) with MaxDepth %d = %q, %v, want %q", tt.in, tt.maxDepth, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{`{"a":}`, `[1,2`, `{"a":1}}`, `tru`} {
+		if _, err := o.PrettyJSON([]byte(in)); err == nil {
+			t.Errorf("PrettyJSON(%s) succeeded", in)
+		}
+	}
+
+	ansi := Profile{Mode: ModeANSI8}
+	th := NewTheme("test").
+		Set("json.key", NewStyle(ANSIColor(4))).
+		Set(StyleNumber, NewStyle(ANSIColor(5))).
+		Set(StylePunctuation, Style{})
+	got, err := PrettyOptions{Theme: th, Profile: &ansi}.PrettyJSON([]byte(`{"a":1}`))
+	if want := "{\n  \x1b[34m\"a\"\x1b[0m: \x1b[35m1\x1b[0m\n}\n"; err != nil || got != want {
+		t.Errorf("PrettyJSON() = %q, %v, want %q", got, err, want)
+	}
+}
+
+// node is a linked list for Dump
+type node struct {
+	Name string
+	next *node
+}
+
+func TestDump(t *testing.T) {
+	p := Profile{NoColor: true}
+	o := PrettyOptions{Profile: &p}
+
+	a := &node{Name: "a"}
+	a.next = &node{Name: "b", next: a}
+	var nilNode *node
+	var nilMap map[string]int
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{nil, "nil"},
+		{nilNode, "nil"},
+		{nilMap, "map[string]int(nil)"},
+		// the unexported fields are written, the pointers back to a node on
+		// the path are not followed
+		{a, "&pencil.node{\n  Name: \"a\",\n  next: &pencil.node{\n    Name: \"b\",\n    next: <cycle>,\n  },\n}"},
+		// a node seen twice but not on the path is written twice
+		{[]*node{a.next, a.next}, "[]*pencil.node{\n  &pencil.node{\n    Name: \"b\",\n    next: &pencil.node{\n      Name: \"a\",\n      next: <cycle>,\n    },\n  },\n  &pencil.node{\n    Name: \"b\",\n    next: &pencil.node{\n      Name: \"a\",\n      next: <cycle>,\n    },\n  },\n}"},
+		// the keys are sorted
+		{map[string]int{"b": 2, "a": 1}, "map[string]int{\n  \"a\": 1,\n  \"b\": 2,\n}"},
+		{struct{ m map[int]bool }{map[int]bool{}}, "struct { m map[int]bool }{\n  m: map[int]bool{},\n}"},
+		{[]byte("hi"), `[]uint8("hi")`},
+		{errors.New("failed"), `*errors.errorString("failed")`},
+	}
+	for _, tt := range tests {
+		if got := o.Dump(tt.v); got != tt.want {
+			t.Errorf("Dump(%#v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	if got, want := o.Dump(m), "map[string]interface {}{\n  \"self\": map[string]interface {}<cycle>,\n}"; got != want {
+		t.Errorf("Dump() of a map holding itself = %q, want %q", got, want)
+	}
+
+	o.MaxDepth = 2
+	if got, want := o.Dump(a), "&pencil.node{\n  Name: \"a\",\n  next: &pencil.node{…},\n}"; got != want {
+		t.Errorf("Dump() with MaxDepth 2 = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package pencil
+
+import (
+	"os"
+	"strings"
+
+	isatty "github.com/mattn/go-isatty"
+)
+
+// Profile is the color capability of an output
+type Profile struct {
+	// NoColor is true if the output must not be colorized
+	NoColor bool
+	// Mode is the richest color mode supported by the output
+	Mode ColorMode
+	// Hyperlinks is true if the output supports the OSC 8 hyperlinks; they
+	// are written only if NoColor is false
+	Hyperlinks bool
+}
+
+// DetectProfile returns the color profile of the output f from its file
+// descriptor and the environment. The first of the following rules that
+// applies decides:
+//
+//  1. NO_COLOR set to a non-empty value disables colors
+//  2. FORCE_COLOR forces a level: "0" or "false" disables colors, "2"
+//     enables the 256 colors, "3" the 24-bit colors and any other value,
+//     e.g. "1", "true" or "yes", the basic colors
+//  3. CLICOLOR_FORCE set to a value other than "0" enables colors
+//  4. CLICOLOR=0 disables colors
+//  5. TERM=dumb disables colors
+//  6. colors are enabled if f is a terminal and disabled otherwise
+//
+// Unless forced by FORCE_COLOR, the color mode is detected from COLORTERM
+// and TERM. Hyperlinks is true if the terminal is known to support them,
+// unless set by FORCE_HYPERLINK ("0" disables them); they are written only
+// with the colors.
+func DetectProfile(f *os.File) Profile {
+	p := detectColors(f)
+	p.Hyperlinks = detectHyperlinks()
+	return p
+}
+
+// detectColors returns the colors part of DetectProfile
+func detectColors(f *os.File) Profile {
+	if v := os.Getenv("NO_COLOR"); v != "" {
+		return Profile{NoColor: true, Mode: detectMode()}
+	}
+	if v, ok := os.LookupEnv("FORCE_COLOR"); ok {
+		switch strings.ToLower(v) {
+		case "0", "false":
+			return Profile{NoColor: true, Mode: detectMode()}
+		case "2":
+			return Profile{Mode: ModeANSI256}
+		case "3":
+			return Profile{Mode: ModeRGB}
+		default:
+			return Profile{Mode: ModeANSI8}
+		}
+	}
+	if v, ok := os.LookupEnv("CLICOLOR_FORCE"); ok && v != "0" {
+		return Profile{Mode: detectMode()}
+	}
+	if os.Getenv("CLICOLOR") == "0" || os.Getenv("TERM") == "dumb" {
+		return Profile{NoColor: true, Mode: detectMode()}
+	}
+	return Profile{NoColor: f == nil || !isTerminal(f.Fd()), Mode: detectMode()}
+}
+
+func isTerminal(fd uintptr) bool {
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// detectMode returns the color mode of the terminal from the environment
+func detectMode() ColorMode {
+	colorterm := strings.ToLower(os.Getenv("COLORTERM"))
+	term := strings.ToLower(os.Getenv("TERM"))
+	switch {
+	case colorterm == "truecolor" || colorterm == "24bit",
+		strings.Contains(term, "truecolor"), strings.Contains(term, "24bit"),
+		strings.HasSuffix(term, "-direct"),
+		os.Getenv("WT_SESSION") != "":
+		return ModeRGB
+	case strings.Contains(term, "256color"):
+		return ModeANSI256
+	default:
+		return ModeANSI8
+	}
+}
+
+// Reload recomputes ColorProfile and NoColor of os.Stdout, e.g. after the
+// program changed its environment or parsed a "--color" flag into it.
+func Reload() {
+	ColorProfile = DetectProfile(os.Stdout)
+	NoColor = ColorProfile.NoColor
+}
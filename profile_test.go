@@ -0,0 +1,63 @@
+package pencil
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDetectProfileForceColor(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Profile
+	}{
+		{"0", Profile{NoColor: true}},
+		{"false", Profile{NoColor: true}},
+		{"", Profile{Mode: ModeANSI8}},
+		{"1", Profile{Mode: ModeANSI8}},
+		{"TRUE", Profile{Mode: ModeANSI8}},
+		{"yes", Profile{Mode: ModeANSI8}},
+		{"on", Profile{Mode: ModeANSI8}},
+		{"2", Profile{Mode: ModeANSI256}},
+		{"3", Profile{Mode: ModeRGB}},
+	}
+	t.Setenv("NO_COLOR", "")
+	for _, tt := range tests {
+		t.Setenv("FORCE_COLOR", tt.value)
+		got := DetectProfile(nil)
+		if got.NoColor != tt.want.NoColor || !got.NoColor && got.Mode != tt.want.Mode {
+			t.Errorf("FORCE_COLOR=%q: DetectProfile() = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestReloadMode(t *testing.T) {
+	defer func(nc bool, p Profile) { NoColor, ColorProfile = nc, p }(NoColor, ColorProfile)
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"FORCE_COLOR": "1"}, "\x1b[1;33mx\x1b[0m"},
+		{map[string]string{"FORCE_COLOR": "2"}, "\x1b[1;38;5;208mx\x1b[0m"},
+		{map[string]string{"FORCE_COLOR": "3"}, "\x1b[1;38;2;255;136;0mx\x1b[0m"},
+		{map[string]string{"CLICOLOR_FORCE": "1", "TERM": "xterm-256color"}, "\x1b[1;38;5;208mx\x1b[0m"},
+		{map[string]string{"CLICOLOR_FORCE": "1", "TERM": "xterm"}, "\x1b[1;33mx\x1b[0m"},
+	}
+	st := NewStyle(RGBColor(255, 136, 0), Bold)
+	for _, tt := range tests {
+		for _, k := range []string{"NO_COLOR", "FORCE_COLOR", "CLICOLOR_FORCE", "CLICOLOR", "COLORTERM", "TERM", "WT_SESSION"} {
+			t.Setenv(k, tt.env[k])
+			if _, ok := tt.env[k]; !ok {
+				os.Unsetenv(k)
+			}
+		}
+		Reload()
+		if got := st.Sprint("x"); got != tt.want {
+			t.Errorf("%v: Sprint() = %q, want %q", tt.env, got, tt.want)
+		}
+		p, ok := ProfileOf(new(strings.Builder))
+		if ok || p.NoColor || p.Mode != ColorProfile.Mode {
+			t.Errorf("%v: ProfileOf() = %+v, %v, want the mode %v of ColorProfile", tt.env, p, ok, ColorProfile.Mode)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package pencil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shyang107/pencil/ansirgb"
+	"golang.org/x/term"
+)
+
+// QueryTimeout is the time QueryPalette waits for the replies of a terminal
+var QueryTimeout = 200 * time.Millisecond
+
+// ErrNoReply is returned by QueryPalette if the terminal did not reply
+var ErrNoReply = errors.New("pencil: no reply from terminal")
+
+// ErrNoDeadline is returned by QueryPalette and QueryTerminal if the terminal
+// does not support read deadlines, so the wait for its replies could not be
+// limited
+var ErrNoDeadline = errors.New("pencil: terminal does not support read deadlines")
+
+var (
+	queried   *ansirgb.Scheme // the last palette reported by QueryPalette
+	queriedMu sync.RWMutex    // protects queried
+)
+
+// QueryPalette asks the terminal tty for the RGB values of the colors 0-15
+// (OSC 4) and its default foreground (OSC 10) and background (OSC 11), and
+// returns them as a scheme which can be set by ansirgb.SetScheme().
+//
+// tty is usually the controlling terminal, os.Open("/dev/tty"); if it is an
+// *os.File referring to a terminal, it is put into raw mode while querying.
+// Any other io.ReadWriter answering the escape sequences, e.g. a
+// pseudo-terminal stand-in, works too, provided it has a SetReadDeadline
+// method; ErrNoDeadline is returned otherwise, as a read without deadline
+// could not be stopped and would consume the input of the user. os.Stdin
+// usually lacks deadline support.
+//
+// A color the terminal does not report is taken from ansirgb.XtermScheme
+// (Foreground and Background stay nil); ErrNoReply is returned if the
+// terminal does not reply within QueryTimeout.
+//
+// This is opt-in: nothing is queried unless QueryPalette is called.
+func QueryPalette(tty io.ReadWriter) (*ansirgb.Scheme, error) {
+	var query bytes.Buffer
+	for i := 0; i < 16; i++ {
+		fmt.Fprintf(&query, "%s]4;%d;?\a", Escape, i)
+	}
+	fmt.Fprintf(&query, "%s]10;?\a%s]11;?\a", Escape, Escape)
+	reply, err := QueryTerminal(tty, query.String())
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ansirgb.Scheme{Name: "terminal", ANSI: ansirgb.XtermScheme.ANSI}
+	n := 0
+	for _, r := range parseOSCReplies(reply) {
+		cl, err := ansirgb.ParseXColor(r.value)
+		if err != nil {
+			continue
+		}
+		switch {
+		case r.code == 4 && r.index >= 0 && r.index < 16:
+			s.ANSI[r.index] = cl
+		case r.code == 10:
+			s.Foreground = cl
+		case r.code == 11:
+			s.Background = cl
+		default:
+			continue
+		}
+		n++
+	}
+	if n == 0 {
+		return nil, ErrNoReply
+	}
+
+	queriedMu.Lock()
+	queried = s
+	queriedMu.Unlock()
+	return s, nil
+}
+
+// QueryTerminal writes the escape sequences query to the terminal tty,
+// followed by a request of the primary device attributes (DA1), and returns
+// the replies up to the one to DA1, which every terminal answers; tty is
+// handled as by QueryPalette. ErrNoReply is returned if the terminal does not
+// reply within QueryTimeout.
+func QueryTerminal(tty io.ReadWriter, query string) ([]byte, error) {
+	d, ok := tty.(deadliner)
+	if !ok || d.SetReadDeadline(time.Now().Add(QueryTimeout)) != nil {
+		return nil, ErrNoDeadline
+	}
+	defer d.SetReadDeadline(time.Time{})
+
+	if fp, ok := tty.(*os.File); ok && term.IsTerminal(int(fp.Fd())) {
+		state, err := term.MakeRaw(int(fp.Fd()))
+		if err != nil {
+			return nil, err
+		}
+		defer term.Restore(int(fp.Fd()), state)
+	}
+
+	if _, err := io.WriteString(tty, query+Escape+"[c"); err != nil {
+		return nil, err
+	}
+	return readReply(tty)
+}
+
+// QueriedPalette returns the palette of the last successful QueryPalette, or
+// nil if the terminal has not been queried
+func QueriedPalette() *ansirgb.Scheme {
+	queriedMu.RLock()
+	defer queriedMu.RUnlock()
+
+	return queried
+}
+
+// deadliner is implemented by readers supporting read deadlines, e.g. *os.File
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// readReply reads from r, which has a read deadline, until the reply of the
+// DA1 query arrives or the deadline expires
+func readReply(r io.Reader) ([]byte, error) {
+	var reply []byte
+	buf := make([]byte, 256)
+	for {
+		n, err := r.Read(buf)
+		reply = append(reply, buf[:n]...)
+		if hasDA1Reply(reply) {
+			return reply, nil
+		}
+		if err != nil {
+			if len(reply) == 0 {
+				return nil, ErrNoReply
+			}
+			return reply, nil
+		}
+	}
+}
+
+// hasDA1Reply reports whether b contains a DA1 reply: ESC [ ? ... c
+func hasDA1Reply(b []byte) bool {
+	i := bytes.Index(b, []byte(Escape+"[?"))
+	if i < 0 {
+		return false
+	}
+	for _, c := range b[i+3:] {
+		switch {
+		case c == 'c':
+			return true
+		case c == ';' || (c >= '0' && c <= '9'):
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// oscReply is a color reply: ESC ] code ; [index ;] value (BEL | ESC \)
+type oscReply struct {
+	code  int
+	index int
+	value string
+}
+
+func parseOSCReplies(b []byte) []oscReply {
+	var replies []oscReply
+	s := string(b)
+	for {
+		i := strings.Index(s, Escape+"]")
+		if i < 0 {
+			return replies
+		}
+		s = s[i+2:]
+		end := strings.IndexAny(s, "\a\x1b")
+		if end < 0 {
+			return replies
+		}
+		body := s[:end]
+		s = s[end:]
+
+		parts := strings.Split(body, ";")
+		code, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		r := oscReply{code: code, index: -1}
+		switch {
+		case code == 4 && len(parts) == 3:
+			r.index, err = strconv.Atoi(parts[1])
+			if err != nil {
+				continue
+			}
+			r.value = parts[2]
+		case len(parts) == 2:
+			r.value = parts[1]
+		default:
+			continue
+		}
+		replies = append(replies, r)
+	}
+}
@@ -0,0 +1,133 @@
+package pencil
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+// fakeTTY returns a pseudo-terminal stand-in which answers each query ending
+// with a DA1 request by reply; it supports read deadlines like a terminal
+// opened by os.Open
+func fakeTTY(t *testing.T, reply string) net.Conn {
+	tty, term := net.Pipe()
+	go func() {
+		defer term.Close()
+		var query []byte
+		buf := make([]byte, 4096)
+		for {
+			n, err := term.Read(buf)
+			if err != nil {
+				return
+			}
+			query = append(query, buf[:n]...)
+			if bytes.HasSuffix(query, []byte(Escape+"[c")) {
+				query = query[:0]
+				if reply != "" {
+					term.Write([]byte(reply))
+				}
+			}
+		}
+	}()
+	t.Cleanup(func() { tty.Close() })
+	return tty
+}
+
+// withQueryTimeout sets QueryTimeout for the test
+func withQueryTimeout(t *testing.T, d time.Duration) {
+	old := QueryTimeout
+	QueryTimeout = d
+	t.Cleanup(func() { QueryTimeout = old })
+}
+
+const (
+	replyRed = Escape + "]4;1;rgb:ffff/0000/0000\a"
+	replyFg  = Escape + "]10;rgb:cccc/cccc/cccc" + Escape + `\`
+	replyBg  = Escape + "]11;rgb:1e/1e/1e\a"
+	replyDA1 = Escape + "[?62;4c"
+)
+
+func TestQueryPalette(t *testing.T) {
+	withQueryTimeout(t, time.Second)
+	s, err := QueryPalette(fakeTTY(t, replyRed+replyFg+replyBg+replyDA1))
+	if err != nil {
+		t.Fatalf("QueryPalette() error = %v", err)
+	}
+	if want := (color.RGBA{0xff, 0, 0, 0xff}); s.ANSI[1] != want {
+		t.Errorf("ANSI[1] = %v, want %v", s.ANSI[1], want)
+	}
+	if s.ANSI[2] != ansirgb.XtermScheme.ANSI[2] {
+		t.Errorf("ANSI[2] = %v, want the xterm color %v", s.ANSI[2], ansirgb.XtermScheme.ANSI[2])
+	}
+	if want := (color.RGBA{0xcc, 0xcc, 0xcc, 0xff}); s.Foreground != want {
+		t.Errorf("Foreground = %v, want %v", s.Foreground, want)
+	}
+	if want := (color.RGBA{0x1e, 0x1e, 0x1e, 0xff}); s.Background != want {
+		t.Errorf("Background = %v, want %v", s.Background, want)
+	}
+	if !s.IsDark() {
+		t.Error("IsDark() = false, want true")
+	}
+	if QueriedPalette() != s {
+		t.Error("QueriedPalette() is not the last palette queried")
+	}
+}
+
+func TestQueryPaletteTimeout(t *testing.T) {
+	// a terminal replying to OSC 11 only and not to DA1: the reply is
+	// read until the timeout
+	withQueryTimeout(t, 50*time.Millisecond)
+	start := time.Now()
+	s, err := QueryPalette(fakeTTY(t, replyBg))
+	if err != nil {
+		t.Fatalf("QueryPalette() error = %v", err)
+	}
+	if d := time.Since(start); d < QueryTimeout {
+		t.Errorf("QueryPalette() returned after %v, before the timeout", d)
+	}
+	if s.Background == nil || s.Foreground != nil {
+		t.Errorf("Foreground, Background = %v, %v, want nil and a color", s.Foreground, s.Background)
+	}
+}
+
+func TestQueryPaletteNoReply(t *testing.T) {
+	withQueryTimeout(t, 50*time.Millisecond)
+	for _, reply := range []string{"", replyDA1} {
+		if _, err := QueryPalette(fakeTTY(t, reply)); err != ErrNoReply {
+			t.Errorf("QueryPalette() with reply %q: error = %v, want ErrNoReply", reply, err)
+		}
+	}
+}
+
+// rwNoDeadline is a terminal stand-in without read deadlines
+type rwNoDeadline struct {
+	bytes.Buffer
+}
+
+func TestQueryTerminalNoDeadline(t *testing.T) {
+	var tty rwNoDeadline
+	if _, err := QueryTerminal(&tty, Escape+"]11;?\a"); !errors.Is(err, ErrNoDeadline) {
+		t.Errorf("QueryTerminal() error = %v, want ErrNoDeadline", err)
+	}
+	if tty.Len() > 0 {
+		t.Errorf("QueryTerminal() wrote %q to a terminal without deadlines", tty.String())
+	}
+}
+
+func TestParseOSCReplies(t *testing.T) {
+	got := parseOSCReplies([]byte(replyRed + "junk" + replyFg + replyDA1))
+	want := []oscReply{{4, 1, "rgb:ffff/0000/0000"}, {10, -1, "rgb:cccc/cccc/cccc"}}
+	if len(got) != len(want) {
+		t.Fatalf("parseOSCReplies() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("reply %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package rgb16b
+
+import (
+	"image/color"
+
+	"github.com/shyang107/pencil"
+)
+
+// Adaptive is a pair of colors for terminals with a light and with a dark
+// background; the one matching pencil.BackgroundIsDark() is used.
+type Adaptive struct {
+	Light color.Color
+	Dark  color.Color
+}
+
+// Pick returns the color matching the background of the terminal
+func (a Adaptive) Pick() color.Color {
+	if pencil.BackgroundIsDark() {
+		return a.Dark
+	}
+	return a.Light
+}
+
+// Color returns a foreground color object matching the background of the
+// terminal
+func (a Adaptive) Color() *Color {
+	return New(a.Pick(), pencil.Foreground)
+}
+
+// Sprint is just like Color.Sprint with the color matching the background
+func (a Adaptive) Sprint(v ...interface{}) string {
+	return a.Color().Sprint(v...)
+}
+
+// Sprintf is just like Color.Sprintf with the color matching the background
+func (a Adaptive) Sprintf(format string, v ...interface{}) string {
+	return a.Color().Sprintf(format, v...)
+}
+
+// Sprintln is just like Color.Sprintln with the color matching the background
+func (a Adaptive) Sprintln(v ...interface{}) string {
+	return a.Color().Sprintln(v...)
+}
+
+// Adaptive grays from low to high contrast on a dark background; the light
+// variants keep the same contrast against a light background
+var (
+	Gray1 = Adaptive{Light: color.RGBA{0xb2, 0xb2, 0xb2, 0xff}, Dark: color.RGBA{0x44, 0x44, 0x44, 0xff}}
+	Gray2 = Adaptive{Light: color.RGBA{0x94, 0x94, 0x94, 0xff}, Dark: color.RGBA{0x76, 0x76, 0x76, 0xff}}
+	Gray3 = Adaptive{Light: color.RGBA{0x4e, 0x4e, 0x4e, 0xff}, Dark: color.RGBA{0xa8, 0xa8, 0xa8, 0xff}}
+	Gray4 = Adaptive{Light: color.RGBA{0x1c, 0x1c, 0x1c, 0xff}, Dark: color.RGBA{0xda, 0xda, 0xda, 0xff}}
+)
+
+// adaptiveString returns a formatted colorful string with the color of a
+// matching the background of the terminal
+func adaptiveString(format string, a Adaptive, v ...interface{}) string {
+	if len(v) == 0 {
+		return a.Color().SprintFunc()(format)
+	}
+
+	return a.Color().SprintfFunc()(format, v...)
+}
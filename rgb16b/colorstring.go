@@ -0,0 +1,21 @@
+package rgb16b
+
+// GrayString1 retrive a formatted string in the low-contrast gray Gray1
+func GrayString1(format string, a ...interface{}) string {
+	return adaptiveString(format, Gray1, a...)
+}
+
+// GrayString2 retrive a formatted string in the gray Gray2
+func GrayString2(format string, a ...interface{}) string {
+	return adaptiveString(format, Gray2, a...)
+}
+
+// GrayString3 retrive a formatted string in the gray Gray3
+func GrayString3(format string, a ...interface{}) string {
+	return adaptiveString(format, Gray3, a...)
+}
+
+// GrayString4 retrive a formatted string in the high-contrast gray Gray4
+func GrayString4(format string, a ...interface{}) string {
+	return adaptiveString(format, Gray4, a...)
+}
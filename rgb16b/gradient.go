@@ -0,0 +1,139 @@
+package rgb16b
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+
+	runewidth "github.com/mattn/go-runewidth"
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/ansirgb"
+	"github.com/shyang107/pencil/dither"
+)
+
+// Gradient colors text character by character with the colors interpolated
+// between its stops, from the first column to the last; the lines of a text
+// are colored alike, so the gradient runs across blocks of text.
+//
+// In the 256 and basic color modes, the colors are dithered as the pixels of
+// an image with one pixel per cell.
+type Gradient struct {
+	// Stops are the colors of the gradient, evenly spaced
+	Stops []color.Color
+	// Background colors the background instead of the characters
+	Background bool
+	// Dither is the dithering of the 256 and basic colors
+	Dither dither.Method
+}
+
+// NewGradient returns a gradient of the colors stops, dithered with
+// Floyd-Steinberg
+func NewGradient(stops ...color.Color) *Gradient {
+	return &Gradient{Stops: stops, Dither: dither.FloydSteinberg}
+}
+
+// Sprint is just like Color.Sprint: the colors are 24-bit colors
+func (g *Gradient) Sprint(a ...interface{}) string {
+	return g.SprintFor(pencil.Profile{NoColor: pencil.NoColor, Mode: pencil.ModeRGB}, a...)
+}
+
+// Sprintf is just like Color.Sprintf: the colors are 24-bit colors
+func (g *Gradient) Sprintf(format string, a ...interface{}) string {
+	return g.Sprint(fmt.Sprintf(format, a...))
+}
+
+// Fprint writes the text of a to w, in the color profile of w (see
+// pencil.ProfileOf)
+func (g *Gradient) Fprint(w io.Writer, a ...interface{}) (n int, err error) {
+	p, _ := pencil.ProfileOf(w)
+	return io.WriteString(w, g.SprintFor(p, a...))
+}
+
+// SprintFor returns the text of a colored for the profile p
+func (g *Gradient) SprintFor(p pencil.Profile, a ...interface{}) string {
+	text := fmt.Sprint(a...)
+	if p.NoColor || len(g.Stops) == 0 {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	width := 0
+	for _, line := range lines {
+		if w := runewidth.StringWidth(line); w > width {
+			width = w
+		}
+	}
+	if width == 0 {
+		return text
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, width, len(lines)))
+	for x := 0; x < width; x++ {
+		c := g.at(x, width)
+		for y := range lines {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var pal color.Palette
+	switch p.Mode {
+	case pencil.ModeANSI8:
+		pal = ansirgb.CurrentBasic()
+	case pencil.ModeANSI256:
+		pal = ansirgb.CurrentPalette()
+	}
+	if pal != nil {
+		dither.Draw(img, pal, g.Dither)
+	}
+
+	var b strings.Builder
+	for y, line := range lines {
+		if y > 0 {
+			b.WriteByte('\n')
+		}
+		var last *pencil.ColorSpec
+		col := 0
+		for _, r := range line {
+			if r != ' ' || g.Background {
+				c := img.NRGBAAt(col, y)
+				spec := pencil.RGBColor(c.R, c.G, c.B)
+				switch p.Mode {
+				case pencil.ModeANSI256:
+					spec = pencil.IndexColor(pencil.ColorCode(pal.Convert(c).(*ansirgb.Color).Code))
+				case pencil.ModeANSI8:
+					spec = pencil.ANSIColor(pencil.ColorCode(pal.Convert(c).(*ansirgb.Color).Code))
+				}
+				if last == nil || *last != *spec {
+					b.WriteString(spec.Sequence(g.Background))
+					last = spec
+				}
+			}
+			b.WriteRune(r)
+			col += runewidth.RuneWidth(r)
+		}
+		if last != nil {
+			b.WriteString(trailing)
+		}
+	}
+	return b.String()
+}
+
+// at returns the color of the column x of width columns
+func (g *Gradient) at(x, width int) color.NRGBA {
+	first := color.NRGBAModel.Convert(g.Stops[0]).(color.NRGBA)
+	if len(g.Stops) == 1 || width == 1 {
+		return first
+	}
+	t := float64(x) / float64(width-1) * float64(len(g.Stops)-1)
+	i := int(t)
+	if i >= len(g.Stops)-1 {
+		return color.NRGBAModel.Convert(g.Stops[len(g.Stops)-1]).(color.NRGBA)
+	}
+	t -= float64(i)
+	c0 := color.NRGBAModel.Convert(g.Stops[i]).(color.NRGBA)
+	c1 := color.NRGBAModel.Convert(g.Stops[i+1]).(color.NRGBA)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t + 0.5)
+	}
+	return color.NRGBA{lerp(c0.R, c1.R), lerp(c0.G, c1.G), lerp(c0.B, c1.B), 0xff}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/shyang107/pencil"
 )
@@ -17,6 +18,8 @@ var (
 	colorCache   = make(map[pencil.Attribute]*Color)
 	colorCacheMu sync.Mutex // protects colorsCache
 
+	// trailing resets the colors after a colored text
+	trailing = pencil.GetDefaultGround() + pencil.GetRest()
 )
 
 // Color is a alias of "color.Color"
@@ -24,6 +27,14 @@ type Color struct {
 	color.Color
 	params  []pencil.Attribute
 	noColor *bool
+	leading atomic.Value // *leading, the cached SGR sequences (see formatIn)
+}
+
+// leading holds the SGR sequences of a Color with the color rgba in each
+// color mode
+type leading struct {
+	rgba [4]uint32
+	seq  [pencil.ModeRGB + 1]string
 }
 
 //---------------------------------------------------------
@@ -46,6 +57,7 @@ func New(cl color.Color, params ...pencil.Attribute) *Color {
 // and create custom color objects. Example: Add(color.FgRed, color.Underline).
 func (c *Color) Add(params ...pencil.Attribute) *Color {
 	c.params = append(c.params, params...)
+	c.leading.Store((*leading)(nil))
 	return c
 }
 
@@ -53,6 +65,7 @@ func (c *Color) prepend(param pencil.Attribute) {
 	c.params = append(c.params, 0)
 	copy(c.params[1:], c.params[0:])
 	c.params[0] = param
+	c.leading.Store((*leading)(nil))
 }
 
 // Set sets the given parameters immediately. It will change the color of
@@ -66,7 +79,7 @@ func Set(cl color.Color, p ...pencil.Attribute) *Color {
 // Unset resets all escape attributes and clears the output. Usually should
 // be called after Set().
 func Unset() {
-	if pencil.NoColor {
+	if p, _ := pencil.ProfileOf(pencil.Output); p.NoColor {
 		return
 	}
 
@@ -75,37 +88,24 @@ func Unset() {
 
 // Set sets the SGR sequence.
 func (c *Color) Set() *Color {
-	if c.isNoColorSet() {
-		return c
-	}
-
-	fmt.Fprintf(pencil.Output, c.format())
-	return c
+	return c.setWriter(pencil.Output)
 }
 
 func (c *Color) unset() {
-	if c.isNoColorSet() {
-		return
-	}
-
-	Unset()
+	c.unsetWriter(pencil.Output)
 }
 
 func (c *Color) setWriter(w io.Writer) *Color {
-	if c.isNoColorSet() {
+	if c.isNoColorSetFor(w) {
 		return c
 	}
 
-	fmt.Fprintf(w, c.format())
+	fmt.Fprint(w, c.formatFor(w))
 	return c
 }
 
 func (c *Color) unsetWriter(w io.Writer) {
-	if c.isNoColorSet() {
-		return
-	}
-
-	if pencil.NoColor {
+	if c.isNoColorSetFor(w) {
 		return
 	}
 
@@ -128,6 +128,12 @@ func (c *Color) wrap(s string) string {
 // ESC[38;2;<r>;<g>;<b>m... Select foreground color
 // ESC[48;2;<r>;<g>;<b>m... Select background color
 func (c *Color) sequence() string {
+	return c.sequenceFor(pencil.ModeRGB)
+}
+
+// sequenceFor is just like sequence with the color converted to mode, e.g.
+// to the closest 256-colors index for pencil.ModeANSI256
+func (c *Color) sequenceFor(mode pencil.ColorMode) string {
 	var colorfmt string
 	format := make([]string, 0)
 	for _, val := range c.params {
@@ -138,13 +144,13 @@ func (c *Color) sequence() string {
 		}
 		switch val {
 		case pencil.Background:
-			colorfmt, _ = pencil.GetBackground(pencil.SelectColorRGB, c.Color)
+			colorfmt = pencil.RGBSequence(c.Color, true, mode)
 		case pencil.DefaultForeground:
 			colorfmt = pencil.GetDefaultForeground()
 		case pencil.DefaultBackground:
 			colorfmt = pencil.GetDefaultBackground()
 		default: // pencil.Foreground
-			colorfmt, _ = pencil.GetForeground(pencil.SelectColorRGB, c.Color)
+			colorfmt = pencil.RGBSequence(c.Color, false, mode)
 		}
 		format = append(format, colorfmt)
 	}
@@ -163,8 +169,7 @@ func (c *Color) Fg() string {
 		return ""
 	}
 
-	format, _ := pencil.GetForeground(pencil.SelectColorRGB, c.Color)
-	return format
+	return pencil.RGBSequence(c.Color, false, pencil.ModeRGB)
 }
 
 // Bg retrive a leading sring in background color
@@ -177,17 +182,44 @@ func (c *Color) Bg() string {
 		return ""
 	}
 
-	format, _ := pencil.GetBackground(pencil.SelectColorRGB, c.Color)
-	return format
+	return pencil.RGBSequence(c.Color, true, pencil.ModeRGB)
 }
 
 func (c *Color) format() string {
 	// return fmt.Sprintf("%s[%sm", escape, c.sequence())
-	return c.sequence()
+	return c.formatIn(pencil.ModeRGB)
+}
+
+// formatFor returns the SGR sequence for the output w, converted to the color
+// mode of w if it carries a color profile
+func (c *Color) formatFor(w io.Writer) string {
+	p, _ := pencil.ProfileOf(w)
+	return c.formatIn(p.Mode)
+}
+
+// formatIn returns sequenceFor(mode); the sequences are computed once and
+// cached until the parameters or the color change
+func (c *Color) formatIn(mode pencil.ColorMode) string {
+	if mode > pencil.ModeRGB {
+		mode = pencil.ModeRGB
+	}
+	var rgba [4]uint32
+	if c.Color != nil {
+		rgba[0], rgba[1], rgba[2], rgba[3] = c.Color.RGBA()
+	}
+	l, _ := c.leading.Load().(*leading)
+	if l == nil || l.rgba != rgba {
+		l = &leading{rgba: rgba}
+		for m := range l.seq {
+			l.seq[m] = c.sequenceFor(pencil.ColorMode(m))
+		}
+		c.leading.Store(l)
+	}
+	return l.seq[mode]
 }
 
 func (c *Color) unformat() string {
-	return pencil.GetDefaultGround() + pencil.GetRest()
+	return trailing
 }
 
 func (c *Color) isNoColorSet() bool {
@@ -200,6 +232,17 @@ func (c *Color) isNoColorSet() bool {
 	return pencil.NoColor
 }
 
+// isNoColorSetFor is isNoColorSet for the output w, which may carry its own
+// color profile (see pencil.Profiler)
+func (c *Color) isNoColorSetFor(w io.Writer) bool {
+	if c.noColor != nil {
+		return *c.noColor
+	}
+
+	p, _ := pencil.ProfileOf(w)
+	return p.NoColor
+}
+
 // func getCachedColor(k pencil.Attribute) *Color {
 // 	colorCacheMu.Lock()
 // 	defer colorCacheMu.Unlock()
@@ -0,0 +1,72 @@
+package rgb16b
+
+import (
+	"image/color"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shyang107/pencil"
+)
+
+func TestChannels(t *testing.T) {
+	// the channels are the 8-bit values of the color, not the 16-bit ones
+	// of RGBA()
+	defer func(noColor bool) { pencil.NoColor = noColor }(pencil.NoColor)
+	pencil.NoColor = false
+	c := New(color.RGBA{0x12, 0x34, 0x56, 0xff}, pencil.Foreground)
+	c.EnableColor()
+	const fg = "38;2;18;52;86"
+	if s := c.Sprint("x"); !strings.Contains(s, fg) {
+		t.Errorf("Sprint() = %q, want %q", s, fg)
+	}
+	if s := c.Fg(); !strings.Contains(s, fg) {
+		t.Errorf("Fg() = %q, want %q", s, fg)
+	}
+	if s := c.Bg(); !strings.Contains(s, "48;2;18;52;86") {
+		t.Errorf("Bg() = %q, want %q", s, "48;2;18;52;86")
+	}
+	bg := New(color.NRGBA{0xff, 0x88, 0, 0xff}, pencil.Background)
+	bg.EnableColor()
+	if s := bg.Sprint("x"); !strings.Contains(s, "48;2;255;136;0") {
+		t.Errorf("Sprint() = %q, want %q", s, "48;2;255;136;0")
+	}
+}
+
+func TestForcedMode(t *testing.T) {
+	// FORCE_COLOR below the 24-bit colors decides the SGR written to
+	// os.Stdout, which is not a pencil.Profiler
+	defer func(noColor bool, p pencil.Profile, stdout *os.File) {
+		pencil.NoColor, pencil.ColorProfile, os.Stdout = noColor, p, stdout
+	}(pencil.NoColor, pencil.ColorProfile, os.Stdout)
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	os.Stdout = w
+	t.Setenv("NO_COLOR", "")
+
+	c := New(color.RGBA{0xff, 0x88, 0, 0xff}, pencil.Foreground)
+	for _, tt := range []struct{ force, want string }{
+		{"1", "\x1b[33mx\x1b[39;49m\x1b[0m"},
+		{"2", "\x1b[38;5;208mx\x1b[39;49m\x1b[0m"},
+		{"3", "\x1b[38;2;255;136;0mx\x1b[39;49m\x1b[0m"},
+	} {
+		t.Setenv("FORCE_COLOR", tt.force)
+		pencil.Reload()
+		c.EnableColor()
+		if _, err := c.Fprint(os.Stdout, "x"); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, len(tt.want))
+		if _, err := io.ReadFull(r, got); err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("FORCE_COLOR=%s: Fprint() wrote %q, want %q", tt.force, got, tt.want)
+		}
+	}
+	w.Close()
+}
@@ -0,0 +1,71 @@
+package scheme
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shyang107/pencil/ansirgb"
+	"gopkg.in/yaml.v3"
+)
+
+// base16ANSI maps the ANSI colors 0-15 to the base16 slots, as base16-shell
+// does
+var base16ANSI = [16]string{
+	"base00", "base08", "base0B", "base0A", "base0D", "base0E", "base0C", "base05",
+	"base03", "base08", "base0B", "base0A", "base0D", "base0E", "base0C", "base07",
+}
+
+// ParseBase16 reads a base16 scheme in YAML, either the classic form with the
+// slots base00 ... base0F at the top level or the newer one with a "palette"
+// mapping. The slots are read as written, so unquoted values such as 123456,
+// which YAML takes for numbers, are colors too. The default foreground is
+// base05 and the background base00.
+func ParseBase16(r io.Reader) (*ansirgb.Scheme, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("base16: %v", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("base16: not a mapping")
+	}
+
+	s := &ansirgb.Scheme{}
+	var name string
+	slots := make(map[string]string)
+	var collect func(m *yaml.Node)
+	collect = func(m *yaml.Node) {
+		for i := 0; i+1 < len(m.Content); i += 2 {
+			k, v := m.Content[i].Value, m.Content[i+1]
+			switch {
+			case k == "palette" && v.Kind == yaml.MappingNode:
+				collect(v)
+			case v.Kind != yaml.ScalarNode:
+			case strings.HasPrefix(strings.ToLower(k), "base"):
+				slots[strings.ToLower(k)] = v.Value
+			case k == "scheme":
+				s.Name = v.Value
+			case k == "name":
+				name = v.Value
+			}
+		}
+	}
+	collect(doc.Content[0])
+	if s.Name == "" {
+		s.Name = name
+	}
+
+	for i, slot := range base16ANSI {
+		v, ok := slots[strings.ToLower(slot)]
+		if !ok {
+			return nil, fmt.Errorf("base16: missing %s", slot)
+		}
+		cl, err := parseColor(v)
+		if err != nil {
+			return nil, fmt.Errorf("base16: %s: %v", slot, err)
+		}
+		s.ANSI[i] = cl
+	}
+	s.Foreground, s.Background = s.ANSI[7], s.ANSI[0]
+	return s, nil
+}
@@ -0,0 +1,118 @@
+package scheme
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+// plistValue is a node of a property list: a <dict>, a <real>, ...
+type plistValue struct {
+	XMLName xml.Name
+	Text    string       `xml:",chardata"`
+	Nodes   []plistValue `xml:",any"`
+}
+
+// ParseITerm reads an iTerm2 color preset (.itermcolors), a property list of
+// "Ansi 0 Color" ... "Ansi 15 Color", "Foreground Color" and "Background
+// Color" with components in [0, 1].
+func ParseITerm(r io.Reader) (*ansirgb.Scheme, error) {
+	var plist struct {
+		Dict plistValue `xml:"dict"`
+	}
+	if err := xml.NewDecoder(r).Decode(&plist); err != nil {
+		return nil, fmt.Errorf("itermcolors: %v", err)
+	}
+
+	s := &ansirgb.Scheme{}
+	var found [16]bool
+	err := eachKey(plist.Dict, func(key string, val plistValue) error {
+		n := -1
+		switch {
+		case strings.HasPrefix(key, "Ansi ") && strings.HasSuffix(key, " Color"):
+			i, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(key, "Ansi "), " Color"))
+			if err != nil || i < 0 || i > 15 {
+				return nil
+			}
+			n = i
+		case key == "Foreground Color", key == "Background Color":
+		default:
+			return nil
+		}
+		cl, err := itermColor(val)
+		if err != nil {
+			return fmt.Errorf("itermcolors: %s: %v", key, err)
+		}
+		switch {
+		case n >= 0:
+			s.ANSI[n], found[n] = cl, true
+		case key == "Foreground Color":
+			s.Foreground = cl
+		default:
+			s.Background = cl
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := check(found); err != nil {
+		return nil, fmt.Errorf("itermcolors: %v", err)
+	}
+	return s, nil
+}
+
+// eachKey calls fn for each <key> and its value of dict
+func eachKey(dict plistValue, fn func(key string, val plistValue) error) error {
+	for i := 0; i+1 < len(dict.Nodes); i++ {
+		if dict.Nodes[i].XMLName.Local != "key" {
+			continue
+		}
+		if err := fn(strings.TrimSpace(dict.Nodes[i].Text), dict.Nodes[i+1]); err != nil {
+			return err
+		}
+		i++
+	}
+	return nil
+}
+
+func itermColor(dict plistValue) (color.RGBA, error) {
+	if dict.XMLName.Local != "dict" {
+		return color.RGBA{}, fmt.Errorf("expected <dict>, got <%s>", dict.XMLName.Local)
+	}
+	var rgb [3]uint8
+	var n int
+	err := eachKey(dict, func(key string, val plistValue) error {
+		var i int
+		switch key {
+		case "Red Component":
+			i = 0
+		case "Green Component":
+			i = 1
+		case "Blue Component":
+			i = 2
+		default:
+			return nil
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(val.Text), 64)
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		rgb[i] = uint8(math.Round(math.Max(0, math.Min(1, v)) * 0xff))
+		n++
+		return nil
+	})
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	if n != 3 {
+		return color.RGBA{}, fmt.Errorf("missing color components")
+	}
+	return color.RGBA{rgb[0], rgb[1], rgb[2], 0xff}, nil
+}
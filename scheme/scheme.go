@@ -0,0 +1,112 @@
+// Package scheme imports terminal color schemes, so the ANSI colors 0-15 and
+// the default foreground/background are known instead of assumed.
+//
+// Supported formats are iTerm2 (.itermcolors), Xresources, Windows Terminal
+// (settings.json or a single scheme object) and base16 (YAML). Use installs
+// a scheme for the nearest-color conversions of ansirgb:
+//
+//	if err := scheme.Use("~/.Xresources"); err != nil {
+//		...
+//	}
+//	code := ansirgb.Index(c) // may now return one of the colors 0-15
+package scheme
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+// Load reads the scheme file of path; the format is chosen by the extension:
+//
+//	.itermcolors  : iTerm2
+//	.json         : Windows Terminal (the first scheme of settings.json)
+//	.yaml, .yml   : base16
+//	otherwise     : Xresources
+//
+// A leading "~/" of path stands for the home directory.
+func Load(path string) (*ansirgb.Scheme, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	var s *ansirgb.Scheme
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".itermcolors":
+		s, err = ParseITerm(fp)
+	case ".json":
+		s, err = ParseWindowsTerminal(fp, "")
+	case ".yaml", ".yml":
+		s, err = ParseBase16(fp)
+	default:
+		s, err = ParseXresources(fp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return s, nil
+}
+
+// Use loads the scheme file of path and sets it as the scheme of ansirgb
+func Use(path string) error {
+	s, err := Load(path)
+	if err != nil {
+		return err
+	}
+	ansirgb.SetScheme(s)
+	return nil
+}
+
+// parseColor parses "#rgb", "#rrggbb", "rrggbb" and the X11 form
+// "rgb:r/g/b" with 1-4 hex digits per channel
+func parseColor(s string) (color.RGBA, error) {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "rgb:") {
+		return ansirgb.ParseXColor(s)
+	}
+
+	hex := strings.TrimPrefix(lower, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, nil
+}
+
+// check returns an error if one of the colors 0-15 is missing
+func check(found [16]bool) error {
+	var missing []string
+	for i, ok := range found {
+		if !ok {
+			missing = append(missing, strconv.Itoa(i))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing ANSI colors %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package scheme
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+// ansi returns the color i of the test schemes
+func ansi(i int) color.RGBA {
+	return color.RGBA{uint8(i * 16), uint8(i), 0x80, 0xff}
+}
+
+func ansiHex(i int) string {
+	c := ansi(i)
+	return fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B)
+}
+
+var (
+	testFg = color.RGBA{0xc5, 0xc8, 0xc6, 0xff}
+	testBg = color.RGBA{0x1d, 0x1f, 0x21, 0xff}
+)
+
+// checkScheme checks that s has the colors ansi(0) ... ansi(15) and the test
+// foreground and background
+func checkScheme(t *testing.T, s *ansirgb.Scheme, name string) {
+	t.Helper()
+	if s.Name != name {
+		t.Errorf("Name = %q, want %q", s.Name, name)
+	}
+	for i, c := range s.ANSI {
+		if c != ansi(i) {
+			t.Errorf("ANSI[%d] = %v, want %v", i, c, ansi(i))
+		}
+	}
+	if s.Foreground != testFg || s.Background != testBg {
+		t.Errorf("Foreground, Background = %v, %v, want %v, %v", s.Foreground, s.Background, testFg, testBg)
+	}
+}
+
+func TestParseXresources(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("! comment\n#define bg #1d1f21\n#include \"other\"\n*.foreground: #c5c8c6\n*.background: bg\n")
+	for i := 0; i < 16; i++ {
+		c := ansi(i)
+		if i == 3 {
+			fmt.Fprintf(&b, "URxvt*color%d: rgb:%02x/%02x/%02x\n", i, c.R, c.G, c.B)
+		} else {
+			fmt.Fprintf(&b, "*.color%d:  #%s\n", i, ansiHex(i))
+		}
+	}
+	b.WriteString("*.color16: #ffffff\n")
+	s, err := ParseXresources(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkScheme(t, s, "")
+
+	for _, tt := range []struct{ src, err string }{
+		{"*.color0: #000\n", "xresources: missing ANSI colors 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15"},
+		{"\n*.color1: #12345\n", "xresources:2: invalid color \"#12345\""},
+	} {
+		if _, err := ParseXresources(strings.NewReader(tt.src)); err == nil || err.Error() != tt.err {
+			t.Errorf("ParseXresources(%q) error = %v, want %q", tt.src, err, tt.err)
+		}
+	}
+}
+
+// itermDict returns the plist dict of the color c
+func itermDict(c color.RGBA) string {
+	return fmt.Sprintf("<dict><key>Alpha Component</key><real>1</real>"+
+		"<key>Blue Component</key><real>%g</real>"+
+		"<key>Green Component</key><real>%g</real>"+
+		"<key>Red Component</key><real>%g</real></dict>",
+		float64(c.B)/0xff, float64(c.G)/0xff, float64(c.R)/0xff)
+}
+
+func TestParseITerm(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+`)
+	for i := 0; i < 16; i++ {
+		fmt.Fprintf(&b, "<key>Ansi %d Color</key>%s\n", i, itermDict(ansi(i)))
+	}
+	fmt.Fprintf(&b, "<key>Foreground Color</key>%s\n<key>Background Color</key>%s\n", itermDict(testFg), itermDict(testBg))
+	b.WriteString("<key>Cursor Color</key><dict></dict>\n</dict>\n</plist>\n")
+	s, err := ParseITerm(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkScheme(t, s, "")
+
+	for _, tt := range []struct{ src, err string }{
+		{"<plist><dict><key>Ansi 0 Color</key>" + itermDict(ansi(0)) + "</dict></plist>", "itermcolors: missing ANSI colors 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15"},
+		{"<plist><dict><key>Ansi 1 Color</key><dict><key>Red Component</key><real>1</real></dict></dict></plist>", "itermcolors: Ansi 1 Color: missing color components"},
+		{"<plist><dict><key>Foreground Color</key><real>1</real></dict></plist>", "itermcolors: Foreground Color: expected <dict>, got <real>"},
+	} {
+		if _, err := ParseITerm(strings.NewReader(tt.src)); err == nil || err.Error() != tt.err {
+			t.Errorf("ParseITerm(%q) error = %v, want %q", tt.src, err, tt.err)
+		}
+	}
+}
+
+// wtJSON returns a Windows Terminal scheme object with the test colors
+func wtJSON(name string) string {
+	keys := []string{
+		"black", "red", "green", "yellow", "blue", "purple", "cyan", "white",
+		"brightBlack", "brightRed", "brightGreen", "brightYellow",
+		"brightBlue", "brightPurple", "brightCyan", "brightWhite",
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `{"name": %q, "foreground": "#C5C8C6", "background": "#1d1f21"`, name)
+	for i, k := range keys {
+		fmt.Fprintf(&b, `, %q: "#%s"`, k, ansiHex(i))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func TestParseWindowsTerminal(t *testing.T) {
+	settings := `{
+	// the schemes, with a comment
+	"profiles": {"defaults": {"font": {"face": "a // b /* c */"}}},
+	/* the first one is the wrong one */
+	"schemes": [
+		{"name": "Other", "black": "#000000"},
+		` + wtJSON("Tomorrow Night") + `
+	]
+}`
+	s, err := ParseWindowsTerminal(strings.NewReader(settings), "tomorrow night")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkScheme(t, s, "Tomorrow Night")
+
+	s, err = ParseWindowsTerminal(strings.NewReader(wtJSON("Single")), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkScheme(t, s, "Single")
+
+	for _, tt := range []struct{ name, err string }{
+		{"", "windows terminal: Other: missing ANSI colors 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15"},
+		{"none", `windows terminal: no scheme named "none"`},
+	} {
+		if _, err := ParseWindowsTerminal(strings.NewReader(settings), tt.name); err == nil || err.Error() != tt.err {
+			t.Errorf("ParseWindowsTerminal(%q) error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestParseBase16(t *testing.T) {
+	// the ANSI colors 9-14 are the same slots as 1-6, and 8 and 15 are
+	// base03 and base07
+	want := [16]string{
+		"000000", "123456", "00ff00", "ffff00", "0000ff", "ff00ff", "00ffff", "c0c0c0",
+		"808080", "123456", "00ff00", "ffff00", "0000ff", "ff00ff", "00ffff", "ffffff",
+	}
+	slots := `base00: 000000
+base01: "111111"
+base02: "222222"
+base03: "808080"
+base04: "444444"
+base05: "c0c0c0"
+base06: "666666"
+base07: "ffffff"
+base08: 123456
+base09: "999999"
+base0A: "ffff00"
+base0B: "00ff00"
+base0C: "00ffff"
+base0D: "0000ff"
+base0E: "ff00ff"
+base0F: "#abc"
+`
+	classic := "scheme: \"Test\"\nauthor: \"me\"\n" + slots
+	palette := "system: \"base16\"\nname: \"Palette\"\npalette:\n  " +
+		strings.ReplaceAll(strings.TrimSuffix(slots, "\n"), "\n", "\n  ") + "\n"
+
+	for _, tt := range []struct{ src, name string }{{classic, "Test"}, {palette, "Palette"}} {
+		s, err := ParseBase16(strings.NewReader(tt.src))
+		if err != nil {
+			t.Errorf("ParseBase16(%q): %v", tt.name, err)
+			continue
+		}
+		if s.Name != tt.name {
+			t.Errorf("Name = %q, want %q", s.Name, tt.name)
+		}
+		for i, c := range s.ANSI {
+			if got := fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B); got != want[i] {
+				t.Errorf("%s: ANSI[%d] = %s, want %s", tt.name, i, got, want[i])
+			}
+		}
+		if s.Foreground != s.ANSI[7] || s.Background != s.ANSI[0] {
+			t.Errorf("%s: Foreground, Background = %v, %v, want base05 and base00", tt.name, s.Foreground, s.Background)
+		}
+	}
+
+	for _, tt := range []struct{ src, err string }{
+		{strings.Replace(classic, "base0E", "base0X", 1), "base16: missing base0E"},
+		{strings.Replace(classic, "123456", "12345g", 1), `base16: base08: invalid color "12345g"`},
+		{"- base00\n", "base16: not a mapping"},
+	} {
+		if _, err := ParseBase16(strings.NewReader(tt.src)); err == nil || err.Error() != tt.err {
+			t.Errorf("ParseBase16() error = %v, want %q", err, tt.err)
+		}
+	}
+}
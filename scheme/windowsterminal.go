@@ -0,0 +1,147 @@
+package scheme
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+// wtScheme is a color scheme of Windows Terminal
+type wtScheme struct {
+	Name         string `json:"name"`
+	Foreground   string `json:"foreground"`
+	Background   string `json:"background"`
+	Black        string `json:"black"`
+	Red          string `json:"red"`
+	Green        string `json:"green"`
+	Yellow       string `json:"yellow"`
+	Blue         string `json:"blue"`
+	Purple       string `json:"purple"`
+	Cyan         string `json:"cyan"`
+	White        string `json:"white"`
+	BrightBlack  string `json:"brightBlack"`
+	BrightRed    string `json:"brightRed"`
+	BrightGreen  string `json:"brightGreen"`
+	BrightYellow string `json:"brightYellow"`
+	BrightBlue   string `json:"brightBlue"`
+	BrightPurple string `json:"brightPurple"`
+	BrightCyan   string `json:"brightCyan"`
+	BrightWhite  string `json:"brightWhite"`
+}
+
+// ParseWindowsTerminal reads the scheme named name from a Windows Terminal
+// settings.json ("schemes" array) or from a single scheme object. An empty
+// name selects the first scheme. Comments in settings.json are allowed.
+func ParseWindowsTerminal(r io.Reader, name string) (*ansirgb.Scheme, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	src = stripJSONComments(src)
+
+	var settings struct {
+		Schemes []wtScheme `json:"schemes"`
+	}
+	if err := json.Unmarshal(src, &settings); err != nil {
+		return nil, fmt.Errorf("windows terminal: %v", err)
+	}
+	schemes := settings.Schemes
+	if len(schemes) == 0 {
+		var single wtScheme
+		if err := json.Unmarshal(src, &single); err != nil {
+			return nil, fmt.Errorf("windows terminal: %v", err)
+		}
+		schemes = []wtScheme{single}
+	}
+
+	for _, ws := range schemes {
+		if name == "" || strings.EqualFold(ws.Name, name) {
+			return ws.scheme()
+		}
+	}
+	return nil, fmt.Errorf("windows terminal: no scheme named %q", name)
+}
+
+func (ws *wtScheme) scheme() (*ansirgb.Scheme, error) {
+	s := &ansirgb.Scheme{Name: ws.Name}
+	values := []string{
+		ws.Black, ws.Red, ws.Green, ws.Yellow, ws.Blue, ws.Purple, ws.Cyan, ws.White,
+		ws.BrightBlack, ws.BrightRed, ws.BrightGreen, ws.BrightYellow,
+		ws.BrightBlue, ws.BrightPurple, ws.BrightCyan, ws.BrightWhite,
+	}
+	var found [16]bool
+	for i, v := range values {
+		if v == "" {
+			continue
+		}
+		cl, err := parseColor(v)
+		if err != nil {
+			return nil, fmt.Errorf("windows terminal: %s: %v", ws.Name, err)
+		}
+		s.ANSI[i], found[i] = cl, true
+	}
+	if err := check(found); err != nil {
+		return nil, fmt.Errorf("windows terminal: %s: %v", ws.Name, err)
+	}
+	if ws.Foreground != "" {
+		cl, err := parseColor(ws.Foreground)
+		if err != nil {
+			return nil, fmt.Errorf("windows terminal: %s: %v", ws.Name, err)
+		}
+		s.Foreground = cl
+	}
+	if ws.Background != "" {
+		cl, err := parseColor(ws.Background)
+		if err != nil {
+			return nil, fmt.Errorf("windows terminal: %s: %v", ws.Name, err)
+		}
+		s.Background = cl
+	}
+	return s, nil
+}
+
+// stripJSONComments removes // and /* */ comments outside of strings
+func stripJSONComments(src []byte) []byte {
+	out := make([]byte, 0, len(src))
+	inString := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(src) {
+				i++
+				out = append(out, src[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			if i < len(src) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			i += 2
+			for i+1 < len(src) && !(src[i] == '*' && src[i+1] == '/') {
+				if src[i] == '\n' {
+					out = append(out, '\n')
+				}
+				i++
+			}
+			i++
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
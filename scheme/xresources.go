@@ -0,0 +1,89 @@
+package scheme
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+// ParseXresources reads the color resources of an X resource file, e.g.
+//
+//	#define base00 #1d1f21
+//	*.foreground: #c5c8c6
+//	*.background: base00
+//	URxvt*color1: rgb:cc/66/66
+//
+// Comments ("!") and simple #define macros are supported.
+func ParseXresources(r io.Reader) (*ansirgb.Scheme, error) {
+	s := &ansirgb.Scheme{}
+	var found [16]bool
+	defines := make(map[string]string)
+
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "!") {
+			continue
+		}
+		if strings.HasPrefix(text, "#define") {
+			fields := strings.Fields(text)
+			if len(fields) >= 3 {
+				defines[fields[1]] = fields[2]
+			}
+			continue
+		}
+		if strings.HasPrefix(text, "#") {
+			continue // other preprocessor directives
+		}
+
+		i := strings.IndexByte(text, ':')
+		if i < 0 {
+			continue
+		}
+		key, value := strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:])
+		if v, ok := defines[value]; ok {
+			value = v
+		}
+		// the resource name is the last component of the key: "URxvt*color1"
+		if j := strings.LastIndexAny(key, ".*"); j >= 0 {
+			key = key[j+1:]
+		}
+		key = strings.ToLower(key)
+
+		switch {
+		case key == "foreground", key == "background":
+			cl, err := parseColor(value)
+			if err != nil {
+				return nil, fmt.Errorf("xresources:%d: %v", line, err)
+			}
+			if key == "foreground" {
+				s.Foreground = cl
+			} else {
+				s.Background = cl
+			}
+		case strings.HasPrefix(key, "color"):
+			n, err := strconv.Atoi(key[len("color"):])
+			if err != nil || n < 0 || n > 15 {
+				continue
+			}
+			cl, err := parseColor(value)
+			if err != nil {
+				return nil, fmt.Errorf("xresources:%d: %v", line, err)
+			}
+			s.ANSI[n], found[n] = cl, true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if err := check(found); err != nil {
+		return nil, fmt.Errorf("xresources: %v", err)
+	}
+	return s, nil
+}
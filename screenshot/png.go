@@ -0,0 +1,111 @@
+package screenshot
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// the size of a cell of the PNG: the bitmap font is 7x13 pixels, plus some
+// spacing between the lines
+const (
+	pngCellWidth  = 7
+	pngCellHeight = 15
+)
+
+// PNG writes text rendered by Image to w as a PNG image
+func PNG(w io.Writer, text string, opts *Options) error {
+	return png.Encode(w, Image(text, opts))
+}
+
+// Image returns text rendered with the bitmap font 7x13 of basicfont
+func Image(text string, opts *Options) *image.RGBA {
+	s := layout(text, opts)
+	pad := s.opts.Padding
+	width := 2*pad + s.columns*pngCellWidth
+	height := s.top() + len(s.lines)*pngCellHeight + pad
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(s.bg), image.Point{}, draw.Src)
+	if s.opts.Chrome {
+		s.pngChrome(img)
+	}
+
+	face := basicfont.Face7x13
+	d := &font.Drawer{Dst: img, Face: face}
+	for row, line := range s.lines {
+		y := s.top() + row*pngCellHeight
+		x := pad
+		for _, c := range line {
+			cw := c.width * pngCellWidth
+			if c.bg != nil {
+				draw.Draw(img, image.Rect(x, y, x+cw, y+pngCellHeight),
+					image.NewUniform(*c.bg), image.Point{}, draw.Src)
+			}
+			if !c.style.Concealed && c.r != ' ' {
+				r := c.r
+				if _, ok := face.GlyphAdvance(r); !ok {
+					r = '?'
+				}
+				d.Src = image.NewUniform(c.fg)
+				baseline := y + face.Ascent + 1
+				d.Dot = fixed.P(x, baseline)
+				d.DrawString(string(r))
+				if c.style.Bold { // overstrike
+					d.Dot = fixed.P(x+1, baseline)
+					d.DrawString(string(r))
+				}
+			}
+			if c.style.Underline {
+				hline(img, x, x+cw, y+pngCellHeight-2, c.fg)
+			}
+			if c.style.CrossedOut {
+				hline(img, x, x+cw, y+pngCellHeight/2, c.fg)
+			}
+			x += cw
+		}
+	}
+	return img
+}
+
+// pngChrome draws the title bar
+func (s *screen) pngChrome(img *image.RGBA) {
+	pad := s.opts.Padding
+	for i, c := range chromeButtons {
+		circle(img, pad+6+i*20, chromeHeight/2+2, 6, c)
+	}
+	if s.opts.Title == "" {
+		return
+	}
+	face := basicfont.Face7x13
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(s.fg), Face: face}
+	width := d.MeasureString(s.opts.Title).Round()
+	x := (img.Bounds().Dx() - width) / 2
+	if x < s.titleLeft() {
+		x = s.titleLeft()
+	}
+	d.Dot = fixed.P(x, chromeHeight/2+6)
+	d.DrawString(s.opts.Title)
+}
+
+func hline(img *image.RGBA, x0, x1, y int, c color.RGBA) {
+	for x := x0; x < x1; x++ {
+		img.SetRGBA(x, y, c)
+	}
+}
+
+func circle(img *image.RGBA, cx, cy, r int, c color.RGBA) {
+	for y := -r; y <= r; y++ {
+		for x := -r; x <= r; x++ {
+			if x*x+y*y <= r*r {
+				img.SetRGBA(cx+x, cy+y, c)
+			}
+		}
+	}
+}
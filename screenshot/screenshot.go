@@ -0,0 +1,193 @@
+// Package screenshot renders text styled with SGR sequences, such as the
+// output of pencil, into terminal-like images for documentation and bug
+// reports:
+//
+//	svg := screenshot.SVG(text, &screenshot.Options{Chrome: true, Title: "demo"})
+//	err := screenshot.PNG(file, text, nil)
+//
+// The SVG uses a monospace font of the viewer; the PNG is drawn with the
+// 7x13 bitmap font of golang.org/x/image/font/basicfont, which covers ASCII
+// only (other characters are drawn as '?').
+package screenshot
+
+import (
+	"image/color"
+	"strings"
+
+	runewidth "github.com/mattn/go-runewidth"
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+// Options are the options of the rendering; nil means the defaults
+type Options struct {
+	// Scheme is the palette of the terminal: the colors 0-15 and the
+	// default colors; the current scheme of ansirgb if nil
+	Scheme *ansirgb.Scheme
+	// Padding is the space around the text in pixels; DefaultPadding if 0
+	Padding int
+	// Chrome draws a title bar with window buttons above the text
+	Chrome bool
+	// Title is the title shown in the title bar
+	Title string
+	// TabWidth is the distance of the tab stops in cells; 8 if 0
+	TabWidth int
+}
+
+// DefaultPadding is the padding unless set by Options
+const DefaultPadding = 12
+
+// chromeHeight is the height of the title bar in pixels
+const chromeHeight = 28
+
+// cell is a character on the screen with its colors
+type cell struct {
+	r     rune
+	width int // columns taken by r, 2 for the wide characters
+	fg    color.RGBA
+	bg    *color.RGBA // nil for the background of the terminal
+	style pencil.SegmentStyle
+}
+
+// screen is the text laid out in cells
+type screen struct {
+	lines   [][]cell
+	columns int
+	fg, bg  color.RGBA
+	opts    Options
+}
+
+func (o *Options) withDefaults() Options {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+	if opts.Scheme == nil {
+		opts.Scheme, _ = ansirgb.CurrentScheme()
+	}
+	if opts.Padding == 0 {
+		opts.Padding = DefaultPadding
+	}
+	if opts.TabWidth <= 0 {
+		opts.TabWidth = 8
+	}
+	return opts
+}
+
+// layout returns the text laid out in cells
+func layout(text string, o *Options) *screen {
+	opts := o.withDefaults()
+	s := &screen{opts: opts}
+	s.fg, s.bg = rgba(opts.Scheme.Foreground, opts.Scheme.ANSI[7]), rgba(opts.Scheme.Background, opts.Scheme.ANSI[0])
+
+	line := []cell{}
+	col := 0
+	for _, seg := range pencil.ParseSegments(strings.TrimRight(text, "\n")) {
+		fg, bg := s.colors(seg.Style)
+		for _, r := range seg.Text {
+			switch r {
+			case '\n':
+				if col > s.columns {
+					s.columns = col
+				}
+				s.lines = append(s.lines, line)
+				line, col = []cell{}, 0
+				continue
+			case '\r':
+				continue
+			case '\t':
+				n := opts.TabWidth - col%opts.TabWidth
+				for i := 0; i < n; i++ {
+					line = append(line, cell{r: ' ', width: 1, fg: fg, bg: bg, style: seg.Style})
+				}
+				col += n
+				continue
+			}
+			w := runewidth.RuneWidth(r)
+			if w == 0 {
+				continue // combining and control characters
+			}
+			line = append(line, cell{r: r, width: w, fg: fg, bg: bg, style: seg.Style})
+			col += w
+		}
+		if col > s.columns {
+			s.columns = col
+		}
+	}
+	s.lines = append(s.lines, line)
+	if s.columns == 0 {
+		s.columns = 1
+	}
+	return s
+}
+
+// colors returns the colors of a cell of style st
+func (s *screen) colors(st pencil.SegmentStyle) (fg color.RGBA, bg *color.RGBA) {
+	fg = s.fg
+	if st.Fg != nil {
+		fg = s.rgb(st.Fg)
+	}
+	if st.Bg != nil {
+		c := s.rgb(st.Bg)
+		bg = &c
+	}
+	if st.Reverse {
+		b := s.bg
+		if bg != nil {
+			b = *bg
+		}
+		f := fg
+		fg, bg = b, &f
+	}
+	if st.Faint {
+		back := s.bg
+		if bg != nil {
+			back = *bg
+		}
+		fg = blend(fg, back)
+	}
+	return fg, bg
+}
+
+// rgb returns the color of spec, with the colors 0-15 from the scheme
+func (s *screen) rgb(spec *pencil.ColorSpec) color.RGBA {
+	if spec.Mode != pencil.ModeRGB && spec.Code >= 0 && spec.Code < 16 {
+		return s.opts.Scheme.ANSI[spec.Code]
+	}
+	return rgba(spec, s.fg)
+}
+
+// rgba returns c as color.RGBA, or def if c is nil
+func rgba(c color.Color, def color.RGBA) color.RGBA {
+	if c == nil {
+		return def
+	}
+	r, g, b, _ := c.RGBA()
+	return color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 0xff}
+}
+
+// blend returns the color halfway between a and b
+func blend(a, b color.RGBA) color.RGBA {
+	return color.RGBA{uint8((int(a.R) + int(b.R)) / 2), uint8((int(a.G) + int(b.G)) / 2),
+		uint8((int(a.B) + int(b.B)) / 2), 0xff}
+}
+
+// top returns the y of the first line, below the chrome
+func (s *screen) top() int {
+	if s.opts.Chrome {
+		return chromeHeight + s.opts.Padding
+	}
+	return s.opts.Padding
+}
+
+// titleLeft returns the least x of the title, right of the buttons
+func (s *screen) titleLeft() int {
+	return s.opts.Padding + 20*len(chromeButtons) + 8
+}
+
+// chromeButtons are the colors of the window buttons of the title bar
+var chromeButtons = []color.RGBA{
+	{0xff, 0x5f, 0x56, 0xff},
+	{0xff, 0xbd, 0x2e, 0xff},
+	{0x27, 0xc9, 0x3f, 0xff},
+}
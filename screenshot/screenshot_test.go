@@ -0,0 +1,40 @@
+package screenshot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLayoutColumns(t *testing.T) {
+	long := "a very long first line of text"
+	tests := []struct {
+		text    string
+		columns int
+	}{
+		{long + "\nab", len(long)},
+		{"ab\n" + long, len(long)},
+		{"ab\n" + long + "\ncd", len(long)},
+		{"\x1b[31m" + long + "\x1b[0m\nab", len(long)},
+		{"tab\there\n", 12},
+		{"", 1},
+	}
+	for _, tt := range tests {
+		if got := layout(tt.text, nil).columns; got != tt.columns {
+			t.Errorf("layout(%q).columns = %d, want %d", tt.text, got, tt.columns)
+		}
+	}
+}
+
+func TestImageWidth(t *testing.T) {
+	long := "a very long first line of text"
+	first := Image(long+"\nab", nil).Bounds().Dx()
+	last := Image("ab\n"+long, nil).Bounds().Dx()
+	if first != last {
+		t.Errorf("width with the long line first = %d, last = %d", first, last)
+	}
+	width := fmt.Sprintf(`width="%d"`, 2*DefaultPadding+len(long)*svgCellWidth)
+	if svg := SVG(long+"\nab", nil); !strings.Contains(svg, width) {
+		t.Errorf("SVG() = %q, want %s", svg, width)
+	}
+}
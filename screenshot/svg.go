@@ -0,0 +1,102 @@
+package screenshot
+
+import (
+	"fmt"
+	"html"
+	"image/color"
+	"strings"
+)
+
+// the size of a cell and the font size of the SVG, in pixels
+const (
+	svgCellWidth  = 8
+	svgCellHeight = 17
+	svgFontSize   = 14
+)
+
+// SVG returns text rendered as an SVG image. Each run of cells of the same
+// style is a text element stretched to the width of its cells, so the
+// columns line up whatever the monospace font of the viewer.
+func SVG(text string, opts *Options) string {
+	s := layout(text, opts)
+	pad := s.opts.Padding
+	width := 2*pad + s.columns*svgCellWidth
+	height := s.top() + len(s.lines)*svgCellHeight + pad
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" rx="6" fill="%s"/>`+"\n", hex(s.bg))
+	if s.opts.Chrome {
+		for i, c := range chromeButtons {
+			fmt.Fprintf(&b, `<circle cx="%d" cy="%d" r="6" fill="%s"/>`+"\n", pad+6+i*20, chromeHeight/2+2, hex(c))
+		}
+		if s.opts.Title != "" {
+			// centered, or after the buttons if the window is narrow
+			x, anchor := width/2, "middle"
+			if x-len(s.opts.Title)*4 < s.titleLeft() {
+				x, anchor = s.titleLeft(), "start"
+			}
+			fmt.Fprintf(&b, `<text x="%d" y="%d" fill="%s" font-family="sans-serif" font-size="13" text-anchor="%s">%s</text>`+"\n",
+				x, chromeHeight/2+6, hex(s.fg), anchor, html.EscapeString(s.opts.Title))
+		}
+	}
+	fmt.Fprintf(&b, `<g font-family="ui-monospace, Menlo, Consolas, 'DejaVu Sans Mono', monospace" font-size="%d" xml:space="preserve">`+"\n",
+		svgFontSize)
+
+	for row, line := range s.lines {
+		y := s.top() + row*svgCellHeight
+		col := 0
+		for i := 0; i < len(line); {
+			// a run of cells of the same style
+			j, w := i, 0
+			for j < len(line) && line[j].style == line[i].style {
+				w += line[j].width
+				j++
+			}
+			s.svgRun(&b, line[i:j], pad+col*svgCellWidth, y, w)
+			col += w
+			i = j
+		}
+	}
+	b.WriteString("</g>\n</svg>\n")
+	return b.String()
+}
+
+// svgRun writes the cells of a run at (x, y), w columns wide
+func (s *screen) svgRun(b *strings.Builder, cells []cell, x, y, w int) {
+	c := cells[0]
+	if c.bg != nil {
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+			x, y, w*svgCellWidth, svgCellHeight, hex(*c.bg))
+	}
+	var text strings.Builder
+	for _, c := range cells {
+		text.WriteRune(c.r)
+	}
+	if strings.TrimSpace(text.String()) == "" || c.style.Concealed {
+		return
+	}
+
+	attrs := []string{fmt.Sprintf(`fill="%s"`, hex(c.fg))}
+	if c.style.Bold {
+		attrs = append(attrs, `font-weight="bold"`)
+	}
+	if c.style.Italic {
+		attrs = append(attrs, `font-style="italic"`)
+	}
+	switch {
+	case c.style.Underline && c.style.CrossedOut:
+		attrs = append(attrs, `text-decoration="underline line-through"`)
+	case c.style.Underline:
+		attrs = append(attrs, `text-decoration="underline"`)
+	case c.style.CrossedOut:
+		attrs = append(attrs, `text-decoration="line-through"`)
+	}
+	fmt.Fprintf(b, `<text x="%d" y="%d" textLength="%d" lengthAdjust="spacingAndGlyphs" %s>%s</text>`+"\n",
+		x, y+svgCellHeight-4, w*svgCellWidth, strings.Join(attrs, " "), html.EscapeString(text.String()))
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
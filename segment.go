@@ -0,0 +1,221 @@
+package pencil
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SegmentStyle is the state of the SGR attributes applying to a Segment
+type SegmentStyle struct {
+	Fg, Bg     *ColorSpec // nil for the default colors
+	Bold       bool
+	Faint      bool
+	Italic     bool
+	Underline  bool
+	Blink      bool
+	Reverse    bool
+	Concealed  bool
+	CrossedOut bool
+	Link       *Hyperlink // set by the OSC 8 sequences, nil outside links
+}
+
+// IsZero reports whether the style is the default one, i.e. after a reset
+func (s SegmentStyle) IsZero() bool {
+	return s == SegmentStyle{}
+}
+
+// equal reports whether s and t look the same; the colors and links are
+// compared by value
+func (s SegmentStyle) equal(t SegmentStyle) bool {
+	fs, bs, ft, bt := s.Fg, s.Bg, t.Fg, t.Bg
+	ls, lt := s.Link, t.Link
+	s.Fg, s.Bg, t.Fg, t.Bg = nil, nil, nil, nil
+	s.Link, t.Link = nil, nil
+	return s == t && equalColorSpec(fs, ft) && equalColorSpec(bs, bt) && equalLink(ls, lt)
+}
+
+// sequence returns the SGR sequence setting s after a reset, empty for the
+// default style; the link is not part of it
+func (s SegmentStyle) sequence() string {
+	var params []string
+	for _, a := range []struct {
+		on    bool
+		param string
+	}{
+		{s.Bold, "1"}, {s.Faint, "2"}, {s.Italic, "3"}, {s.Underline, "4"},
+		{s.Blink, "5"}, {s.Reverse, "7"}, {s.Concealed, "8"}, {s.CrossedOut, "9"},
+	} {
+		if a.on {
+			params = append(params, a.param)
+		}
+	}
+	if s.Fg != nil {
+		params = append(params, s.Fg.params(false))
+	}
+	if s.Bg != nil {
+		params = append(params, s.Bg.params(true))
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return Escape + "[" + strings.Join(params, ";") + "m"
+}
+
+func equalColorSpec(a, b *ColorSpec) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalLink(a, b *Hyperlink) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Segment is a run of text in a single style, as produced by the escape
+// sequences preceding it
+type Segment struct {
+	Text  string
+	Style SegmentStyle
+}
+
+// ParseSegments splits s, which may contain SGR sequences such as the ones
+// written by pencil, into segments of text with their style, including the
+// OSC 8 hyperlinks; the other escape sequences are dropped.
+func ParseSegments(s string) []Segment {
+	var p SegmentParser
+	return p.Parse([]byte(s))
+}
+
+// SegmentParser is ParseSegments for a stream: the style and a sequence
+// split across calls of Parse carry over to the next call.
+type SegmentParser struct {
+	escapeScanner
+	style SegmentStyle
+}
+
+// Parse returns the segments of the text of p; adjacent texts of the same
+// style are joined
+func (sp *SegmentParser) Parse(p []byte) []Segment {
+	var segs []Segment
+	sp.scan(p, func(text []byte) error {
+		if n := len(segs); n > 0 && segs[n-1].Style.equal(sp.style) {
+			segs[n-1].Text += string(text)
+		} else {
+			segs = append(segs, Segment{Text: string(text), Style: sp.style})
+		}
+		return nil
+	}, func(seq []byte) error {
+		if isSGR(seq) {
+			// a reset of the attributes does not end a link
+			link := sp.style.Link
+			sp.style = applySGR(sp.style, string(seq[2:len(seq)-1]))
+			sp.style.Link = link
+		} else if link, ok := parseLink(seq); ok {
+			sp.style.Link = link
+		}
+		return nil
+	})
+	return segs
+}
+
+// Style returns the current style of the parser
+func (sp *SegmentParser) Style() SegmentStyle {
+	return sp.style
+}
+
+// applySGR returns the style s after the SGR parameters params, e.g.
+// "1;38;5;202" or "38:2::255:136:0"
+func applySGR(s SegmentStyle, params string) SegmentStyle {
+	if params == "" {
+		return SegmentStyle{}
+	}
+	fields := strings.Split(params, ";")
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		sub := strings.Split(f, ":")
+		n, err := strconv.Atoi(sub[0])
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 0:
+			s = SegmentStyle{}
+		case n == 1:
+			s.Bold = true
+		case n == 2:
+			s.Faint = true
+		case n == 3:
+			s.Italic = true
+		case n == 4:
+			s.Underline = len(sub) < 2 || sub[1] != "0"
+		case n == 5 || n == 6:
+			s.Blink = true
+		case n == 7:
+			s.Reverse = true
+		case n == 8:
+			s.Concealed = true
+		case n == 9:
+			s.CrossedOut = true
+		case n == 21: // doubly underlined
+			s.Underline = true
+		case n == 22:
+			s.Bold, s.Faint = false, false
+		case n == 23:
+			s.Italic = false
+		case n == 24:
+			s.Underline = false
+		case n == 25:
+			s.Blink = false
+		case n == 27:
+			s.Reverse = false
+		case n == 28:
+			s.Concealed = false
+		case n == 29:
+			s.CrossedOut = false
+		case n >= 30 && n <= 37:
+			s.Fg = ANSIColor(ColorCode(n - 30))
+		case n >= 90 && n <= 97:
+			s.Fg = ANSIColor(ColorCode(n - 90 + 8))
+		case n == 39:
+			s.Fg = nil
+		case n >= 40 && n <= 47:
+			s.Bg = ANSIColor(ColorCode(n - 40))
+		case n >= 100 && n <= 107:
+			s.Bg = ANSIColor(ColorCode(n - 100 + 8))
+		case n == 49:
+			s.Bg = nil
+		case n == 38 || n == 48 || n == 58:
+			var args []string
+			if len(sub) > 1 {
+				args = sub[1:]
+				if len(args) == 5 && args[0] == "2" { // with a colorspace id
+					args = append(args[:1], args[2:]...)
+				}
+			} else {
+				switch {
+				case i+2 < len(fields) && fields[i+1] == "5":
+					args = fields[i+1 : i+3]
+				case i+4 < len(fields) && fields[i+1] == "2":
+					args = fields[i+1 : i+5]
+				}
+				i += len(args)
+			}
+			if len(args) == 0 {
+				continue
+			}
+			spec := extendedColor(args)
+			switch {
+			case spec == nil || n == 58: // malformed, underline color
+			case n == 38:
+				s.Fg = spec
+			default:
+				s.Bg = spec
+			}
+		}
+	}
+	return s
+}
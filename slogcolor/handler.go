@@ -0,0 +1,249 @@
+// Package slogcolor provides a log/slog handler writing colored, human
+// readable lines:
+//
+//	15:04:05.000 INFO  server started addr=:8080 tls=true
+//
+// The styles are taken from a pencil.Theme by the names
+//
+//	log.timestamp, log.level.debug, log.level.info, log.level.warning,
+//	log.level.error, log.message, log.key, log.value, log.number, log.bool,
+//	log.error, log.punctuation, log.source
+//
+// which fall back to "timestamp", "debug", "key" ... (see pencil.Theme.Lookup),
+// so a theme can define log styles without repeating the common ones:
+//
+//	logger := slog.New(slogcolor.NewHandler(os.Stderr, nil))
+//
+// The colors follow the profile of the writer (see pencil.NewWriter): the
+// lines are plain text if it is not a terminal or NO_COLOR is set.
+package slogcolor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/shyang107/pencil"
+)
+
+// DefaultTimeFormat is the layout of the timestamps unless set by Options
+const DefaultTimeFormat = "15:04:05.000"
+
+// Options are the options of a Handler; the zero value is usable
+type Options struct {
+	// Level is the minimum level logged; slog.LevelInfo if nil
+	Level slog.Leveler
+	// AddSource adds the file:line of the log call to each line
+	AddSource bool
+	// TimeFormat is the layout of the timestamps, DefaultTimeFormat if
+	// empty; "-" omits them
+	TimeFormat string
+	// Theme gives the styles; pencil.CurrentTheme() at the time of the log
+	// call if nil
+	Theme *pencil.Theme
+	// Profile overrides the color profile detected from the writer
+	Profile *pencil.Profile
+}
+
+// Handler is a slog.Handler writing colored lines. It is safe for concurrent
+// use: each record is written in a single write, and the handlers derived
+// by WithAttrs and WithGroup share the lock of their parent.
+type Handler struct {
+	opts    Options
+	w       io.Writer
+	profile pencil.Profile
+	mu      *sync.Mutex
+
+	attrs  string // the formatted attributes of WithAttrs
+	prefix string // the groups of WithGroup, e.g. "request.header."
+}
+
+// NewHandler returns a Handler writing to w; opts may be nil
+func NewHandler(w io.Writer, opts *Options) *Handler {
+	h := &Handler{w: w, mu: new(sync.Mutex)}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Profile != nil {
+		h.profile = *h.opts.Profile
+	} else {
+		h.profile = pencil.NewWriter(w).Profile
+	}
+	return h
+}
+
+// Enabled implements slog.Handler
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// WithAttrs implements slog.Handler
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	c := *h
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	t := h.theme()
+	for _, a := range attrs {
+		h.appendAttr(&b, t, h.prefix, a)
+	}
+	c.attrs = b.String()
+	return &c
+}
+
+// WithGroup implements slog.Handler
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.prefix = h.prefix + name + "."
+	return &c
+}
+
+// Handle implements slog.Handler
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	t := h.theme()
+	var b strings.Builder
+
+	if layout := h.opts.TimeFormat; layout != "-" && !r.Time.IsZero() {
+		if layout == "" {
+			layout = DefaultTimeFormat
+		}
+		b.WriteString(h.style(t, "log.timestamp", r.Time.Format(layout)))
+		b.WriteByte(' ')
+	}
+	b.WriteString(h.style(t, levelStyle(r.Level), fmt.Sprintf("%-5s", r.Level.String())))
+	b.WriteByte(' ')
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		if frame.File != "" {
+			src := filepath.Base(filepath.Dir(frame.File)) + "/" + filepath.Base(frame.File) +
+				":" + strconv.Itoa(frame.Line)
+			b.WriteString(h.style(t, "log.source", src))
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteString(h.style(t, "log.message", quoteMessage(r.Message)))
+
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		h.appendAttr(&b, t, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *Handler) theme() *pencil.Theme {
+	if h.opts.Theme != nil {
+		return h.opts.Theme
+	}
+	return pencil.CurrentTheme()
+}
+
+// style returns s in the style name of t for the profile of the handler
+func (h *Handler) style(t *pencil.Theme, name, s string) string {
+	return t.Style(name).SprintFor(h.profile, s)
+}
+
+// appendAttr appends " key=value" to b; the attributes of groups are
+// appended with their keys prefixed by the group, e.g. " req.method=GET"
+func (h *Handler) appendAttr(b *strings.Builder, t *pencil.Theme, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, g := range a.Value.Group() {
+			h.appendAttr(b, t, prefix, g)
+		}
+		return
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(h.style(t, "log.key", prefix+a.Key))
+	b.WriteString(h.style(t, "log.punctuation", "="))
+	name, text := valueStyle(a.Value)
+	b.WriteString(h.style(t, name, text))
+}
+
+// levelStyle returns the style name of level
+func levelStyle(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "log.level.error"
+	case level >= slog.LevelWarn:
+		return "log.level.warning"
+	case level >= slog.LevelInfo:
+		return "log.level.info"
+	default:
+		return "log.level.debug"
+	}
+}
+
+// valueStyle returns the style name and the text of v
+func valueStyle(v slog.Value) (name, text string) {
+	switch v.Kind() {
+	case slog.KindInt64, slog.KindUint64, slog.KindFloat64:
+		return "log.number", v.String()
+	case slog.KindDuration:
+		return "log.number", v.Duration().String()
+	case slog.KindBool:
+		return "log.bool", v.String()
+	case slog.KindTime:
+		return "log.timestamp", v.Time().Format(time.RFC3339)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return "log.error", quote(err.Error())
+		}
+	}
+	return "log.value", quote(v.String())
+}
+
+// quote returns s quoted if it is empty or contains spaces, quotes, '=' or
+// unprintable characters, as slog.TextHandler does
+func quote(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == '"' || r == '=' || !unicode.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// quoteMessage returns msg quoted if it contains a newline or unprintable
+// characters, as slog.TextHandler does, so a record stays on its line; the
+// spaces of a message are left unquoted
+func quoteMessage(msg string) string {
+	for _, r := range msg {
+		if r != ' ' && !unicode.IsPrint(r) {
+			return strconv.Quote(msg)
+		}
+	}
+	return msg
+}
@@ -0,0 +1,188 @@
+package slogcolor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/shyang107/pencil"
+)
+
+var plain = &pencil.Profile{NoColor: true}
+
+// parseLine parses a plain line of a Handler with the time in RFC 3339 into
+// the map of slogtest: the dotted keys are nested groups
+func parseLine(t *testing.T, line string) map[string]interface{} {
+	m := map[string]interface{}{}
+	fields := splitFields(line)
+	if len(fields) > 0 {
+		if _, err := time.Parse(time.RFC3339Nano, fields[0]); err == nil {
+			m[slog.TimeKey] = fields[0]
+			fields = fields[1:]
+		}
+	}
+	if len(fields) < 2 {
+		t.Fatalf("line %q has no level and message", line)
+	}
+	m[slog.LevelKey] = fields[0]
+	m[slog.MessageKey] = unquote(fields[1])
+	for _, f := range fields[2:] {
+		i := strings.IndexByte(f, '=')
+		if i < 0 {
+			t.Fatalf("line %q: attribute %q without =", line, f)
+		}
+		keys := strings.Split(f[:i], ".")
+		g := m
+		for _, k := range keys[:len(keys)-1] {
+			sub, ok := g[k].(map[string]interface{})
+			if !ok {
+				sub = map[string]interface{}{}
+				g[k] = sub
+			}
+			g = sub
+		}
+		g[keys[len(keys)-1]] = unquote(f[i+1:])
+	}
+	return m
+}
+
+// splitFields splits line at the spaces outside quotes
+func splitFields(line string) []string {
+	var fields []string
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && quoted && i+1 < len(line):
+			b.WriteByte(c)
+			i++
+			b.WriteByte(line[i])
+			continue
+		case c == '"':
+			quoted = !quoted
+		case c == ' ' && !quoted:
+			if b.Len() > 0 {
+				fields = append(fields, b.String())
+				b.Reset()
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	if b.Len() > 0 {
+		fields = append(fields, b.String())
+	}
+	return fields
+}
+
+func unquote(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return s
+}
+
+func TestSlogtest(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{TimeFormat: time.RFC3339Nano, Profile: plain})
+	err := slogtest.TestHandler(h, func() []map[string]interface{} {
+		var ms []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			ms = append(ms, parseLine(t, line))
+		}
+		return ms
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHandlerLines(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{TimeFormat: "-", Profile: plain, Level: slog.LevelDebug})
+	l := slog.New(h)
+
+	l.Debug("debug")
+	l.Info("started", "addr", ":8080", "tls", true, "took", 1500*time.Millisecond)
+	l.With("id", 7).WithGroup("req").With("method", "GET").Warn("slow", slog.Group("header", "host", "x y"))
+	l.WithGroup("").With("a", "b").WithGroup("").Info("empty groups")
+	l.WithGroup("g").Info("no attrs")
+	l.Error("failed", "err", errors.New("no such file"), "empty", "")
+	l.Info("two\nlines", "v", "a=b")
+	want := `DEBUG debug
+INFO  started addr=:8080 tls=true took=1.5s
+WARN  slow id=7 req.method=GET req.header.host="x y"
+INFO  empty groups a=b
+INFO  no attrs
+ERROR failed err="no such file" empty=""
+INFO  "two\nlines" v="a=b"
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Handler wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHandlerColors(t *testing.T) {
+	var buf bytes.Buffer
+	p := pencil.Profile{Mode: pencil.ModeANSI8}
+	th := pencil.NewTheme("test").
+		Set("level.error", pencil.NewStyle(pencil.ANSIColor(1), pencil.Bold)).
+		Set("key", pencil.NewStyle(pencil.ANSIColor(4))).
+		Set("log.number", pencil.NewStyle(pencil.ANSIColor(5)))
+	slog.New(NewHandler(&buf, &Options{TimeFormat: "-", Profile: &p, Theme: th})).Error("x", "n", 3)
+	want := "\x1b[1;31mERROR\x1b[0m x \x1b[34mn\x1b[0m=\x1b[35m3\x1b[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Handler wrote %q, want %q", got, want)
+	}
+}
+
+func TestHandlerNotTerminal(t *testing.T) {
+	// a writer which is not a terminal gets plain lines
+	for _, k := range []string{"FORCE_COLOR", "CLICOLOR_FORCE"} {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+	var buf bytes.Buffer
+	slog.New(NewHandler(&buf, nil)).Info("plain", "k", 1)
+	if got := buf.String(); strings.Contains(got, "\x1b") || !strings.HasSuffix(got, " INFO  plain k=1\n") {
+		t.Errorf("Handler wrote %q, want a plain line", got)
+	}
+}
+
+func TestHandlerConcurrent(t *testing.T) {
+	// the handlers derived from one share its lock: the lines are whole
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{TimeFormat: "-", Profile: &pencil.Profile{Mode: pencil.ModeRGB}})
+	const goroutines, lines = 8, 200
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l := slog.New(h).With("g", i).WithGroup("w")
+			for j := 0; j < lines; j++ {
+				l.InfoContext(context.Background(), "line", "j", j)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	got := strings.Split(strings.TrimSuffix(pencil.Strip(buf.String()), "\n"), "\n")
+	if len(got) != goroutines*lines {
+		t.Fatalf("Handler wrote %d lines, want %d", len(got), goroutines*lines)
+	}
+	for _, line := range got {
+		if !strings.HasPrefix(line, "INFO  line g=") || !strings.Contains(line, " w.j=") {
+			t.Fatalf("Handler wrote the broken line %q", line)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package pencil
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/shyang107/pencil/ansirgb"
+)
+
+// ColorSpec describes a single colour of a Style in one of the color modes:
+//
+//	ModeANSI8  : Code is a basic color index (0-7) or a hi-intensity one (8-15)
+//	ModeANSI256: Code is a 256-colors index (0-255)
+//	ModeRGB    : RGB holds the 24-bit color
+type ColorSpec struct {
+	Mode ColorMode
+	Code ColorCode
+	RGB  color.RGBA
+}
+
+// ANSIColor returns a ColorSpec of the basic color index code (0-15)
+func ANSIColor(code ColorCode) *ColorSpec {
+	return &ColorSpec{Mode: ModeANSI8, Code: code}
+}
+
+// IndexColor returns a ColorSpec of the 256-colors index code (0-255)
+func IndexColor(code ColorCode) *ColorSpec {
+	return &ColorSpec{Mode: ModeANSI256, Code: code}
+}
+
+// RGBColor returns a ColorSpec of the 24-bit color (r, g, b)
+func RGBColor(r, g, b uint8) *ColorSpec {
+	return &ColorSpec{Mode: ModeRGB, RGB: color.RGBA{r, g, b, 0xff}}
+}
+
+// RGBA implements color.Color; the basic and 256-colors are looked up in the
+// current scheme of ansirgb (see ansirgb.SetScheme)
+func (c *ColorSpec) RGBA() (r, g, b, a uint32) {
+	if c.Mode == ModeRGB {
+		return c.RGB.RGBA()
+	}
+	return ansirgb.Lookup(int(c.Code)).RGBA()
+}
+
+// In returns the color converted to mode if mode is poorer than the mode of
+// the color, e.g. a 24-bit color to the closest 256-colors index; otherwise
+// the color itself
+func (c *ColorSpec) In(mode ColorMode) *ColorSpec {
+	if mode >= c.Mode {
+		return c
+	}
+	switch {
+	case mode == ModeANSI256: // from ModeRGB
+		return IndexColor(ColorCode(ansirgb.Index(c.RGB)))
+	case c.Mode == ModeANSI256 && c.Code < 16:
+		return ANSIColor(c.Code)
+	default:
+		return ANSIColor(ColorCode(ansirgb.ConvertBasic(c).Code))
+	}
+}
+
+// Sequence returns the SGR sequence selecting the color as foreground, or as
+// background if background is true, e.g. "\x1b[38;5;202m"
+func (c *ColorSpec) Sequence(background bool) string {
+	return Escape + "[" + c.params(background) + "m"
+}
+
+// IndexSequence returns the SGR sequence selecting the 256-colors code as
+// foreground (or background) color, converted to mode
+func IndexSequence(code ColorCode, background bool, mode ColorMode) string {
+	return IndexColor(code).In(mode).Sequence(background)
+}
+
+// RGBSequence returns the SGR sequence selecting the color cl as foreground
+// (or background) color, converted to mode
+func RGBSequence(cl color.Color, background bool, mode ColorMode) string {
+	rgba := color.RGBAModel.Convert(cl).(color.RGBA)
+	return (&ColorSpec{Mode: ModeRGB, RGB: rgba}).In(mode).Sequence(background)
+}
+
+// params returns the SGR parameters of the color, e.g. "31", "38;5;202" or
+// "48;2;255;136;0"
+func (c *ColorSpec) params(background bool) string {
+	switch c.Mode {
+	case ModeANSI8:
+		base := 30
+		if background {
+			base = 40
+		}
+		if c.Code > 7 {
+			base += 60
+		}
+		return strconv.Itoa(base + int(c.Code)%8)
+	case ModeANSI256:
+		if c.Code < 0 { // transparent
+			if background {
+				return strconv.Itoa(int(DefaultBackground))
+			}
+			return strconv.Itoa(int(DefaultForeground))
+		}
+		sel := Foreground
+		if background {
+			sel = Background
+		}
+		return fmt.Sprintf("%d;5;%d", sel, c.Code)
+	default: // ModeRGB
+		sel := Foreground
+		if background {
+			sel = Background
+		}
+		return fmt.Sprintf("%d;2;%d;%d;%d", sel, c.RGB.R, c.RGB.G, c.RGB.B)
+	}
+}
+
+// String returns the textual form of the color which is accepted back by the
+// colour parser of package theme: an ANSI name ("red", "hiblue"), a 256-colors
+// index ("202") or a hex triplet ("#ff8800")
+func (c *ColorSpec) String() string {
+	switch c.Mode {
+	case ModeANSI8:
+		if c.Code >= 0 && int(c.Code) < len(ansiColorNames) {
+			return ansiColorNames[c.Code]
+		}
+		return strconv.Itoa(int(c.Code))
+	case ModeANSI256:
+		return strconv.Itoa(int(c.Code))
+	default: // ModeRGB
+		return fmt.Sprintf("#%02x%02x%02x", c.RGB.R, c.RGB.G, c.RGB.B)
+	}
+}
+
+// ansiColorNames are the names of the basic colors 0-15
+var ansiColorNames = []string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"hiblack", "hired", "higreen", "hiyellow", "hiblue", "himagenta", "hicyan", "hiwhite",
+}
+
+// ANSIColorByName returns the basic color index (0-15) of name, e.g. "red" or
+// "hiblue"; the prefix "bright" is accepted as a synonym of "hi"
+func ANSIColorByName(name string) (ColorCode, bool) {
+	name = strings.ToLower(name)
+	if strings.HasPrefix(name, "bright") {
+		name = "hi" + strings.TrimLeft(name[len("bright"):], "-_")
+	}
+	for i, n := range ansiColorNames {
+		if n == name {
+			return ColorCode(i), true
+		}
+	}
+	return 0, false
+}
+
+// attributeNames are the names of SGR attributes used by the textual form of
+// a Style
+var attributeNames = map[Attribute]string{
+	Bold:         "bold",
+	Faint:        "faint",
+	Italic:       "italic",
+	Underline:    "underline",
+	BlinkSlow:    "blink",
+	BlinkRapid:   "rapidblink",
+	ReverseVideo: "reverse",
+	Concealed:    "concealed",
+	CrossedOut:   "strike",
+}
+
+// attributeAliases are the additional names accepted by AttributeByName
+var attributeAliases = map[string]Attribute{
+	"dim":          Faint,
+	"hidden":       Concealed,
+	"crossedout":   CrossedOut,
+	"inverse":      ReverseVideo,
+	"strikeout":    CrossedOut,
+	"underlined":   Underline,
+	"blinkslow":    BlinkSlow,
+	"blinkrapid":   BlinkRapid,
+	"reversevideo": ReverseVideo,
+}
+
+// AttributeName returns the name of the SGR attribute a, e.g. "bold"; an
+// attribute without a name is written "sgr" followed by its number, e.g.
+// "sgr53"
+func AttributeName(a Attribute) string {
+	if name, ok := attributeNames[a]; ok {
+		return name
+	}
+	return "sgr" + strconv.Itoa(int(a))
+}
+
+// AttributeByName returns the SGR attribute of name, e.g. Bold of "bold" or
+// the attribute 53 of "sgr53"
+func AttributeByName(name string) (Attribute, bool) {
+	name = strings.ToLower(name)
+	for a, n := range attributeNames {
+		if n == name {
+			return a, true
+		}
+	}
+	if strings.HasPrefix(name, "sgr") {
+		n, err := strconv.Atoi(name[3:])
+		return Attribute(n), err == nil && n >= 0 && n <= 255
+	}
+	a, ok := attributeAliases[name]
+	return a, ok
+}
+
+// Style combines a foreground color, a background color and SGR attributes.
+// A nil Fg or Bg leaves the terminal's default color. A Link makes the styled
+// text a hyperlink.
+type Style struct {
+	Fg    *ColorSpec
+	Bg    *ColorSpec
+	Attrs []Attribute
+	Link  *Hyperlink
+}
+
+// NewStyle returns a Style with the foreground color fg and attributes attrs
+func NewStyle(fg *ColorSpec, attrs ...Attribute) Style {
+	return Style{Fg: fg, Attrs: attrs}
+}
+
+// On returns a copy of the style with the background color bg
+func (s Style) On(bg *ColorSpec) Style {
+	s.Bg = bg
+	return s
+}
+
+// With returns a copy of the style with the additional attributes attrs
+func (s Style) With(attrs ...Attribute) Style {
+	s.Attrs = append(append([]Attribute{}, s.Attrs...), attrs...)
+	return s
+}
+
+// LinkTo returns a copy of the style making the text a hyperlink to url; id
+// may be empty (see Hyperlink)
+func (s Style) LinkTo(url, id string) Style {
+	s.Link = &Hyperlink{URL: url, ID: id}
+	return s
+}
+
+// IsZero returns true if the style has no colors, no attributes and no link
+func (s Style) IsZero() bool {
+	return !s.hasSGR() && s.Link == nil
+}
+
+// hasSGR reports whether the style has colors or attributes
+func (s Style) hasSGR() bool {
+	return s.Fg != nil || s.Bg != nil || len(s.Attrs) > 0
+}
+
+// Sequence returns the SGR sequence setting the style, e.g. "\x1b[1;31m"; it
+// is empty for a style without colors and attributes. The link is not part
+// of it.
+func (s Style) Sequence() string {
+	return s.SequenceFor(ModeRGB)
+}
+
+// SequenceFor is just like Sequence with the colors converted to mode
+func (s Style) SequenceFor(mode ColorMode) string {
+	if !s.hasSGR() {
+		return ""
+	}
+	params := make([]string, 0, len(s.Attrs)+2)
+	for _, a := range s.Attrs {
+		params = append(params, strconv.Itoa(int(a)))
+	}
+	if s.Fg != nil {
+		params = append(params, s.Fg.In(mode).params(false))
+	}
+	if s.Bg != nil {
+		params = append(params, s.Bg.In(mode).params(true))
+	}
+	return Escape + "[" + strings.Join(params, ";") + "m"
+}
+
+// wrap wraps the s string with the style, the colors converted to the mode
+// of ColorProfile. The string is ready to be printed.
+func (s Style) wrap(str string) string {
+	if !NoColor && s.hasSGR() {
+		str = s.SequenceFor(ColorProfile.Mode) + str + GetRest()
+	}
+	if s.Link != nil {
+		str = s.Link.Sprint(str)
+	}
+	return str
+}
+
+// Sprint formats using the default formats for its operands and returns the
+// resulting string wrapped with the style.
+func (s Style) Sprint(a ...interface{}) string {
+	return s.wrap(fmt.Sprint(a...))
+}
+
+// Sprintf formats according to a format specifier and returns the resulting
+// string wrapped with the style.
+func (s Style) Sprintf(format string, a ...interface{}) string {
+	return s.wrap(fmt.Sprintf(format, a...))
+}
+
+// Sprintln is just like Sprint, but spaces are always added between operands
+// and a newline is appended.
+func (s Style) Sprintln(a ...interface{}) string {
+	if s.Link != nil { // the newline is not part of the link
+		str := fmt.Sprintln(a...)
+		return s.wrap(str[:len(str)-1]) + "\n"
+	}
+	return s.wrap(fmt.Sprintln(a...))
+}
+
+// SprintFor is just like Sprint for an output of the color profile p: the
+// colors are converted to p.Mode and omitted if p.NoColor is true, and the
+// link falls back to the URL in parentheses as in Hyperlink.SprintFor
+func (s Style) SprintFor(p Profile, a ...interface{}) string {
+	str := fmt.Sprint(a...)
+	if !p.NoColor && s.hasSGR() {
+		str = s.SequenceFor(p.Mode) + str + GetRest()
+	}
+	if s.Link != nil {
+		str = s.Link.SprintFor(p, str)
+	}
+	return str
+}
+
+// String returns the textual form of the style, e.g. "bold #ff8800 on 236",
+// which is accepted back by theme.ParseStyle; the link is left out
+func (s Style) String() string {
+	words := make([]string, 0, len(s.Attrs)+3)
+	for _, a := range s.Attrs {
+		words = append(words, AttributeName(a))
+	}
+	if s.Fg != nil {
+		words = append(words, s.Fg.String())
+	}
+	if s.Bg != nil {
+		words = append(words, "on", s.Bg.String())
+	}
+	if len(words) == 0 {
+		return "none"
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,19 @@
+package termimg
+
+import "github.com/shyang107/pencil/dither"
+
+// Dither is the dithering applied when the colors are converted to the 256
+// or basic colors; it is a method of package dither, which Options.Dither
+// accepts as well
+type Dither = dither.Method
+
+// Dithering methods; see package dither for the others
+const (
+	// NoDither converts each color to the closest one
+	NoDither = dither.None
+	// FloydSteinberg diffuses the error of the conversion to the
+	// neighboring pixels
+	FloydSteinberg = dither.FloydSteinberg
+	// Ordered adds the thresholds of a Bayer matrix to the pixels
+	Ordered = dither.Bayer
+)
@@ -0,0 +1,80 @@
+package termimg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// KittyOptions are the options of EncodeKitty
+type KittyOptions struct {
+	// RGBA sends the raw pixels instead of a PNG image, which is faster to
+	// encode but larger
+	RGBA bool
+	// Columns and Rows are the size of the image in cells, into which the
+	// terminal scales it; the size of the image in pixels if 0
+	Columns, Rows int
+}
+
+// kittyChunk is the largest size of the data of an escape sequence
+const kittyChunk = 4096
+
+// EncodeKitty writes img to w with the graphics protocol of kitty: the image
+// is sent as a PNG (or raw RGBA), encoded in base64 in chunks of 4096 bytes,
+// and displayed at the cursor. opts may be nil.
+func EncodeKitty(w io.Writer, img image.Image, opts *KittyOptions) error {
+	var o KittyOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	var data bytes.Buffer
+	control := "a=T,q=2"
+	if o.RGBA {
+		b := img.Bounds()
+		control += fmt.Sprintf(",f=32,s=%d,v=%d", b.Dx(), b.Dy())
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				data.Write([]byte{c.R, c.G, c.B, c.A})
+			}
+		}
+	} else {
+		control += ",f=100"
+		if err := png.Encode(&data, img); err != nil {
+			return err
+		}
+	}
+	if o.Columns > 0 {
+		control += fmt.Sprintf(",c=%d", o.Columns)
+	}
+	if o.Rows > 0 {
+		control += fmt.Sprintf(",r=%d", o.Rows)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(data.Bytes())
+	bw := bufio.NewWriter(w)
+	for first := true; first || encoded != ""; first = false {
+		chunk := encoded
+		if len(chunk) > kittyChunk {
+			chunk = chunk[:kittyChunk]
+		}
+		encoded = encoded[len(chunk):]
+		more := 0
+		if encoded != "" {
+			more = 1
+		}
+		// the control data go with the first chunk only
+		if first {
+			fmt.Fprintf(bw, "\x1b_G%s,m=%d;%s\x1b\\", control, more, chunk)
+		} else {
+			fmt.Fprintf(bw, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,87 @@
+package termimg
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/shyang107/pencil"
+)
+
+// Protocol is the way images are sent to the terminal
+type Protocol int
+
+// Protocols
+const (
+	// Blocks draws the images with characters (see Mode); every terminal
+	// supports it
+	Blocks Protocol = iota
+	// Sixel sends the images as Sixel graphics
+	Sixel
+	// Kitty sends the images with the graphics protocol of kitty
+	Kitty
+	// Auto uses the protocol returned by DetectProtocol
+	Auto
+)
+
+var protocolNames = []string{"blocks", "sixel", "kitty", "auto"}
+
+// String returns the name of the protocol, e.g. "sixel"
+func (p Protocol) String() string {
+	if p >= 0 && int(p) < len(protocolNames) {
+		return protocolNames[p]
+	}
+	return "unknown"
+}
+
+// DetectProtocol returns the protocol supported by the terminal according to
+// the environment: Kitty in kitty, Ghostty and WezTerm, Sixel in the
+// terminals known for it, e.g. foot and mlterm, and Blocks otherwise, or
+// inside tmux and screen, which do not pass the images through.
+//
+// QueryProtocol asks the terminal itself.
+func DetectProtocol() Protocol {
+	term := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("TMUX") != "" || strings.HasPrefix(term, "screen") || strings.HasPrefix(term, "tmux"):
+		return Blocks
+	case os.Getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty",
+		program == "ghostty", program == "WezTerm":
+		return Kitty
+	case strings.Contains(term, "sixel"), strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "mlterm"),
+		strings.HasPrefix(term, "yaft"), strings.HasPrefix(term, "contour"):
+		return Sixel
+	}
+	return Blocks
+}
+
+// kittyQuery asks the terminal whether it supports the kitty graphics
+// protocol with a 1x1 image; it replies "OK" if it does
+const kittyQuery = "\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\"
+
+// QueryProtocol asks the terminal tty (see pencil.QueryTerminal) for its
+// support of the kitty graphics protocol and of Sixel, reported by the
+// attribute 4 of its device attributes; Kitty is preferred.
+func QueryProtocol(tty io.ReadWriter) (Protocol, error) {
+	reply, err := pencil.QueryTerminal(tty, kittyQuery)
+	if err != nil {
+		return Blocks, err
+	}
+	if bytes.Contains(reply, []byte("\x1b_Gi=31;OK")) {
+		return Kitty, nil
+	}
+	// the device attributes: ESC [ ? 62 ; 4 ; ... c
+	if i := bytes.Index(reply, []byte("\x1b[?")); i >= 0 {
+		attrs := reply[i+3:]
+		if j := bytes.IndexByte(attrs, 'c'); j >= 0 {
+			for _, a := range strings.Split(string(attrs[:j]), ";") {
+				if a == "4" {
+					return Sixel, nil
+				}
+			}
+		}
+	}
+	return Blocks, nil
+}
@@ -0,0 +1,58 @@
+package termimg
+
+import (
+	"image"
+	"image/color"
+)
+
+// resample returns img scaled to w x h pixels, each pixel the average of the
+// pixels of img it covers. The transparent pixels are blended with bg unless
+// it is nil.
+func resample(img image.Image, w, h int, bg color.Color) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	var br, bgr, bb uint32
+	if bg != nil {
+		br, bgr, bb, _ = bg.RGBA()
+	}
+	for y := 0; y < h; y++ {
+		y0, y1 := span(y, h, sh)
+		for x := 0; x < w; x++ {
+			x0, x1 := span(x, w, sw)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(b.Min.X+sx, b.Min.Y+sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			// the average, premultiplied by alpha
+			r, g, bl, a = r/n, g/n, bl/n, a/n
+			if bg != nil {
+				r += uint64(br) * (0xffff - a) / 0xffff
+				g += uint64(bgr) * (0xffff - a) / 0xffff
+				bl += uint64(bb) * (0xffff - a) / 0xffff
+				a = 0xffff
+			}
+			if a == 0 {
+				continue
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				uint8(r * 0xff / a), uint8(g * 0xff / a), uint8(bl * 0xff / a), uint8(a >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// span returns the pixels of the source, of size n, covered by the pixel i
+// of the destination, of size m; at least one
+func span(i, m, n int) (from, to int) {
+	from, to = i*n/m, (i+1)*n/m
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
@@ -0,0 +1,123 @@
+package termimg
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/dither"
+)
+
+// EncodeSixel writes img to w as Sixel graphics, its colors quantized to
+// pal with the dithering m; pal is pencil.PalettePlan9 if nil. The pixels
+// more than half transparent are left transparent.
+//
+// pal has at most 256 colors, the color registers of most terminals;
+// dither.ErrPaletteSize is returned for a larger palette, such as
+// ansirgb.Palette with a scheme set, which is not truncated silently.
+func EncodeSixel(w io.Writer, img image.Image, pal color.Palette, m dither.Method) error {
+	if pal == nil {
+		pal = pencil.PalettePlan9
+	}
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	p, err := dither.Paletted(img, pal, m)
+	if err != nil {
+		return err
+	}
+	mask := make([]bool, width*height) // the opaque pixels
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			_, _, _, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			mask[y*width+x] = a >= 0x8000
+		}
+	}
+	visible := func(x, y int) bool {
+		return mask[y*width+x]
+	}
+
+	bw := bufio.NewWriter(w)
+	// P2 = 1: the pixels which are not set keep the background
+	fmt.Fprintf(bw, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+
+	// the colors used, in percents
+	used := make([]bool, len(pal))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if visible(x, y) {
+				used[p.ColorIndexAt(b.Min.X+x, b.Min.Y+y)] = true
+			}
+		}
+	}
+	for i, c := range pal {
+		if used[i] {
+			r, g, bl, _ := c.RGBA()
+			fmt.Fprintf(bw, "#%d;2;%d;%d;%d", i, percent(r), percent(g), percent(bl))
+		}
+	}
+
+	// the image is drawn in bands of six rows, one color at a time
+	bits := make([]byte, width)
+	for y0 := 0; y0 < height; y0 += 6 {
+		band := make(map[int]bool)
+		var order []int
+		for y := y0; y < y0+6 && y < height; y++ {
+			for x := 0; x < width; x++ {
+				if i := int(p.ColorIndexAt(b.Min.X+x, b.Min.Y+y)); visible(x, y) && !band[i] {
+					band[i] = true
+					order = append(order, i)
+				}
+			}
+		}
+		for k, i := range order {
+			for x := range bits {
+				bits[x] = 0
+				for dy := 0; dy < 6 && y0+dy < height; dy++ {
+					if int(p.ColorIndexAt(b.Min.X+x, b.Min.Y+y0+dy)) == i && visible(x, y0+dy) {
+						bits[x] |= 1 << dy
+					}
+				}
+			}
+			if k > 0 {
+				bw.WriteByte('$') // back to the start of the band
+			}
+			fmt.Fprintf(bw, "#%d", i)
+			writeSixels(bw, bits)
+		}
+		bw.WriteByte('-') // the next band
+	}
+	bw.WriteString("\x1b\\")
+	return bw.Flush()
+}
+
+// writeSixels writes the sixels of bits, the runs of more than three equal
+// sixels compressed as "!count sixel"
+func writeSixels(w *bufio.Writer, bits []byte) {
+	// the trailing empty sixels need not be written
+	end := len(bits)
+	for end > 0 && bits[end-1] == 0 {
+		end--
+	}
+	for x := 0; x < end; {
+		n := 1
+		for x+n < end && bits[x+n] == bits[x] {
+			n++
+		}
+		c := '?' + bits[x]
+		if n > 3 {
+			fmt.Fprintf(w, "!%d%c", n, c)
+		} else {
+			for i := 0; i < n; i++ {
+				w.WriteByte(c)
+			}
+		}
+		x += n
+	}
+}
+
+func percent(v uint32) uint32 {
+	return (v*100 + 0x7fff) / 0xffff
+}
@@ -0,0 +1,356 @@
+// Package termimg draws images in a terminal with block characters whose
+// foreground and background colors are the pixels of the image:
+//
+//	termimg.Fprint(os.Stdout, img, &termimg.Options{Width: 60, Mode: termimg.Quadrant})
+//
+// The colors are written in the color mode of the profile, converted to the
+// 256 colors (ansirgb.Palette) or the basic colors (ansirgb.Basic), with
+// optional dithering (see package dither). Without colors the image is
+// drawn as ASCII art.
+package termimg
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"strings"
+
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/ansirgb"
+	"github.com/shyang107/pencil/dither"
+)
+
+// Mode is the way the pixels are drawn with characters
+type Mode int
+
+// Modes of drawing
+const (
+	// HalfBlock draws 1x2 pixels per cell with '▀', the upper pixel in the
+	// foreground color and the lower one in the background color
+	HalfBlock Mode = iota
+	// Quadrant draws 2x2 pixels per cell with the quadrant characters, e.g.
+	// '▚', in two colors
+	Quadrant
+	// Braille draws 2x4 pixels per cell with the dots of the braille
+	// characters, e.g. '⣷', in two colors
+	Braille
+)
+
+// cellPixels returns the number of pixels drawn in a cell
+func (m Mode) cellPixels() (w, h int) {
+	switch m {
+	case Quadrant:
+		return 2, 2
+	case Braille:
+		return 2, 4
+	default:
+		return 1, 2
+	}
+}
+
+// Options are the options of the drawing; nil means the defaults
+type Options struct {
+	// Width is the width in cells; the width of the image, up to
+	// DefaultWidth, if 0
+	Width int
+	// Height is the height in cells; if 0 it follows the aspect ratio of the
+	// image, a cell being twice as high as wide
+	Height int
+	// Mode is the way of drawing the pixels
+	Mode Mode
+	// Dither is the dithering of the 256 and basic colors; with
+	// dither.None the colors of each cell are converted instead of its
+	// pixels
+	Dither dither.Method
+	// Background is the color the transparent pixels are blended with; if
+	// nil they are left in the default background of the terminal
+	Background color.Color
+	// Profile is the color profile of the output; the profile of os.Stdout
+	// if nil
+	Profile *pencil.Profile
+	// Protocol is the way the image is sent to the terminal; Sixel and Kitty
+	// fall back to Blocks if the output has no colors
+	Protocol Protocol
+}
+
+// DefaultWidth is the largest width of an image unless set by Options
+const DefaultWidth = 80
+
+// the size of a cell in pixels assumed to scale the Sixel images to the
+// size set by Options
+const (
+	sixelCellWidth  = 10
+	sixelCellHeight = 20
+)
+
+// Render returns img drawn for a terminal, one line per row of cells
+func Render(img image.Image, opts *Options) string {
+	var b strings.Builder
+	newRenderer(opts).render(&b, img)
+	return b.String()
+}
+
+// Fprint writes img drawn for a terminal to w
+func Fprint(w io.Writer, img image.Image, opts *Options) error {
+	_, err := io.WriteString(w, Render(img, opts))
+	return err
+}
+
+type renderer struct {
+	opts    Options
+	profile pencil.Profile
+	palette color.Palette // nil in ModeRGB
+}
+
+func newRenderer(opts *Options) *renderer {
+	r := &renderer{}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.Profile != nil {
+		r.profile = *r.opts.Profile
+	} else {
+		r.profile = pencil.Profile{NoColor: pencil.NoColor, Mode: pencil.ColorProfile.Mode}
+	}
+	switch r.profile.Mode {
+	case pencil.ModeANSI8:
+		r.palette = ansirgb.CurrentBasic()
+	case pencil.ModeANSI256:
+		r.palette = ansirgb.CurrentPalette()
+	}
+	return r
+}
+
+// encode writes img to w with the graphics protocol Kitty or Sixel
+func (r *renderer) encode(w io.Writer, img image.Image, protocol Protocol) error {
+	if protocol == Kitty {
+		return EncodeKitty(w, img, &KittyOptions{Columns: r.opts.Width, Rows: r.opts.Height})
+	}
+	if r.opts.Width > 0 || r.opts.Height > 0 {
+		cols, rows := r.size(img.Bounds(), 1)
+		img = resample(img, cols*sixelCellWidth, rows*sixelCellHeight, r.opts.Background)
+	}
+	return EncodeSixel(w, img, nil, r.opts.Dither)
+}
+
+// size returns the size of the drawing in cells
+func (r *renderer) size(bounds image.Rectangle, pw int) (cols, rows int) {
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return 0, 0
+	}
+	cols, rows = r.opts.Width, r.opts.Height
+	switch {
+	case cols <= 0 && rows > 0:
+		cols = 2 * rows * w / h
+	case cols <= 0:
+		cols = (w + pw - 1) / pw
+		if cols > DefaultWidth {
+			cols = DefaultWidth
+		}
+	}
+	if cols < 1 {
+		cols = 1
+	}
+	if rows <= 0 {
+		rows = (cols*h + w) / (2 * w) // rounded
+		if rows < 1 {
+			rows = 1
+		}
+	}
+	return cols, rows
+}
+
+func (r *renderer) render(b *strings.Builder, img image.Image) {
+	if r.profile.NoColor {
+		r.renderASCII(b, img)
+		return
+	}
+	protocol := r.opts.Protocol
+	if protocol == Auto {
+		protocol = DetectProtocol()
+	}
+	if protocol == Kitty || protocol == Sixel {
+		// the image is drawn with blocks if it cannot be encoded
+		var g strings.Builder
+		if err := r.encode(&g, img, protocol); err == nil {
+			b.WriteString(g.String())
+			b.WriteByte('\n')
+			return
+		}
+	}
+	pw, ph := r.opts.Mode.cellPixels()
+	cols, rows := r.size(img.Bounds(), pw)
+	if cols == 0 {
+		return
+	}
+	px := resample(img, cols*pw, rows*ph, r.opts.Background)
+	if r.palette != nil && r.opts.Dither != dither.None {
+		dither.Draw(px, r.palette, r.opts.Dither)
+	}
+
+	cell := make([]color.NRGBA, pw*ph)
+	for y := 0; y < rows; y++ {
+		var fg, bg *pencil.ColorSpec // the colors set on the line
+		for x := 0; x < cols; x++ {
+			for i := range cell {
+				cell[i] = px.NRGBAAt(x*pw+i%pw, y*ph+i/pw)
+			}
+			ch, cfg, cbg := r.cell(cell)
+			setColor(b, &fg, r.spec(cfg), false)
+			setColor(b, &bg, r.spec(cbg), true)
+			b.WriteRune(ch)
+		}
+		if fg != nil || bg != nil {
+			b.WriteString(pencil.GetRest())
+		}
+		b.WriteByte('\n')
+	}
+}
+
+// setColor writes the sequence of c unless it is the current color
+func setColor(b *strings.Builder, cur **pencil.ColorSpec, c *pencil.ColorSpec, background bool) {
+	switch {
+	case c == nil && *cur == nil:
+		return
+	case c == nil && background:
+		b.WriteString(pencil.GetDefaultBackground())
+	case c == nil:
+		b.WriteString(pencil.GetDefaultForeground())
+	case *cur != nil && **cur == *c:
+		return
+	default:
+		b.WriteString(c.Sequence(background))
+	}
+	*cur = c
+}
+
+// spec returns c in the color mode of the profile; nil for nil
+func (r *renderer) spec(c *color.NRGBA) *pencil.ColorSpec {
+	switch {
+	case c == nil:
+		return nil
+	case r.profile.Mode == pencil.ModeANSI256:
+		return pencil.IndexColor(pencil.ColorCode(r.palette.Convert(*c).(*ansirgb.Color).Code))
+	case r.profile.Mode == pencil.ModeANSI8:
+		return pencil.ANSIColor(pencil.ColorCode(r.palette.Convert(*c).(*ansirgb.Color).Code))
+	default:
+		return pencil.RGBColor(c.R, c.G, c.B)
+	}
+}
+
+// quadrants are the characters of Quadrant by the bits of the foreground
+// pixels: 1 upper left, 2 upper right, 4 lower left, 8 lower right
+var quadrants = []rune(" ▘▝▀▖▌▞▛▗▚▐▜▄▙▟█")
+
+// brailleDots are the bits of the braille dots of the pixels of a cell, in
+// the order of the pixels
+var brailleDots = []rune{0x01, 0x08, 0x02, 0x10, 0x04, 0x20, 0x40, 0x80}
+
+// cell returns the character drawing the pixels of a cell, in row order,
+// with its colors; a nil color is the default color
+func (r *renderer) cell(px []color.NRGBA) (ch rune, fg, bg *color.NRGBA) {
+	if r.opts.Mode == HalfBlock {
+		top, bottom := px[0], px[1]
+		switch {
+		case opaque(top) && opaque(bottom):
+			return '▀', &top, &bottom
+		case opaque(top):
+			return '▀', &top, nil
+		case opaque(bottom):
+			return '▄', &bottom, nil
+		default:
+			return ' ', nil, nil
+		}
+	}
+
+	// the pixels are split in two colors: the lighter ones are drawn in
+	// the foreground color, unless some are transparent, in which case the
+	// opaque ones are
+	var n int
+	var sum float64
+	transparent := false
+	for _, c := range px {
+		if opaque(c) {
+			n++
+			sum += ansirgb.Luminance(c)
+		} else {
+			transparent = true
+		}
+	}
+	if n == 0 {
+		return ' ', nil, nil
+	}
+	mean := sum / float64(n)
+	var bits int
+	var fgs, bgs []color.NRGBA
+	for i, c := range px {
+		if opaque(c) && (transparent || ansirgb.Luminance(c) > mean) {
+			bits |= 1 << i
+			fgs = append(fgs, c)
+		} else if opaque(c) {
+			bgs = append(bgs, c)
+		}
+	}
+	if len(fgs) == 0 { // a uniform cell
+		fgs = bgs
+		bits = 1<<len(px) - 1
+	}
+	f := average(fgs)
+	fg = &f
+	if len(bgs) > 0 {
+		b := average(bgs)
+		bg = &b
+	}
+	if r.opts.Mode == Quadrant {
+		return quadrants[bits], fg, bg
+	}
+	ch = 0x2800
+	for i, dot := range brailleDots {
+		if bits&(1<<i) != 0 {
+			ch |= dot
+		}
+	}
+	return ch, fg, bg
+}
+
+func opaque(c color.NRGBA) bool {
+	return c.A >= 0x80
+}
+
+func average(cs []color.NRGBA) color.NRGBA {
+	var r, g, b int
+	for _, c := range cs {
+		r, g, b = r+int(c.R), g+int(c.G), b+int(c.B)
+	}
+	n := len(cs)
+	return color.NRGBA{uint8(r / n), uint8(g / n), uint8(b / n), 0xff}
+}
+
+// asciiRamp are the characters of the ASCII art, from the darkest to the
+// lightest
+const asciiRamp = " .:-=+*#%@"
+
+// renderASCII draws img with characters of asciiRamp, a pixel per cell
+func (r *renderer) renderASCII(b *strings.Builder, img image.Image) {
+	cols, rows := r.size(img.Bounds(), 1)
+	if cols == 0 {
+		return
+	}
+	px := resample(img, cols, rows, r.opts.Background)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			c := px.NRGBAAt(x, y)
+			if !opaque(c) {
+				b.WriteByte(' ')
+				continue
+			}
+			i := int(ansirgb.Luminance(c) * float64(len(asciiRamp)))
+			if i >= len(asciiRamp) {
+				i = len(asciiRamp) - 1
+			}
+			b.WriteByte(asciiRamp[i])
+		}
+		b.WriteByte('\n')
+	}
+}
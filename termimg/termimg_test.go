@@ -0,0 +1,264 @@
+package termimg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/dither"
+)
+
+var (
+	red  = color.NRGBA{0xff, 0, 0, 0xff}
+	blue = color.NRGBA{0, 0, 0xff, 0xff}
+)
+
+// sixelImage is a Sixel image decoded from its DCS sequence
+type sixelImage struct {
+	params        string         // P1;P2;P3
+	width, height int            // raster attributes
+	colors        map[int][3]int // color registers in percents
+	pixels        map[[2]int]int // color register of each pixel set
+}
+
+// decodeSixel decodes the Sixel DCS sequence s: raster attributes, RGB color
+// definitions, color selections, sixels, repeats, "$" and "-"
+func decodeSixel(t *testing.T, s string) *sixelImage {
+	t.Helper()
+	m := regexp.MustCompile(`^\x1bP([0-9;]*)q"1;1;(\d+);(\d+)(.*)\x1b\\$`).FindStringSubmatch(s)
+	if m == nil {
+		t.Fatalf("not a Sixel sequence with raster attributes: %q", s)
+	}
+	img := &sixelImage{params: m[1], colors: map[int][3]int{}, pixels: map[[2]int]int{}}
+	img.width, _ = strconv.Atoi(m[2])
+	img.height, _ = strconv.Atoi(m[3])
+
+	data := m[4]
+	number := func() int {
+		i := 0
+		for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+			i++
+		}
+		n, err := strconv.Atoi(data[:i])
+		if err != nil {
+			t.Fatalf("number expected at %q", data)
+		}
+		data = data[i:]
+		return n
+	}
+	x, y, reg := 0, 0, -1
+	for data != "" {
+		c := data[0]
+		data = data[1:]
+		switch {
+		case c == '#':
+			reg = number()
+			if strings.HasPrefix(data, ";2;") {
+				data = data[3:]
+				var rgb [3]int
+				for k := range rgb {
+					if k > 0 {
+						data = strings.TrimPrefix(data, ";")
+					}
+					rgb[k] = number()
+				}
+				img.colors[reg] = rgb
+			}
+		case c == '$':
+			x = 0
+		case c == '-':
+			x, y = 0, y+6
+		case c == '!':
+			n := number()
+			bits := data[0] - '?'
+			data = data[1:]
+			for i := 0; i < n; i++ {
+				img.set(x, y, bits, reg)
+				x++
+			}
+		case c >= '?' && c <= '~':
+			img.set(x, y, c-'?', reg)
+			x++
+		default:
+			t.Fatalf("unexpected %q in the Sixel data", c)
+		}
+	}
+	return img
+}
+
+func (img *sixelImage) set(x, y int, bits byte, reg int) {
+	for dy := 0; dy < 6; dy++ {
+		if bits&(1<<dy) != 0 {
+			img.pixels[[2]int{x, y + dy}] = reg
+		}
+	}
+}
+
+func TestEncodeSixel(t *testing.T) {
+	// 5x8 pixels over two bands: red on the left, blue on the right, one
+	// transparent pixel and a run of 5 for the repeat
+	src := image.NewNRGBA(image.Rect(10, 20, 15, 28))
+	for y := 20; y < 28; y++ {
+		for x := 10; x < 15; x++ {
+			c := red
+			if x >= 13 && y < 26 {
+				c = blue
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+	src.SetNRGBA(10, 20, color.NRGBA{})
+	pal := color.Palette{color.Black, red, blue, color.White}
+
+	var b bytes.Buffer
+	if err := EncodeSixel(&b, src, pal, dither.None); err != nil {
+		t.Fatalf("EncodeSixel() error = %v", err)
+	}
+	img := decodeSixel(t, b.String())
+	if img.params != "0;1;0" {
+		t.Errorf("parameters %q, want transparent background (0;1;0)", img.params)
+	}
+	if img.width != 5 || img.height != 8 {
+		t.Errorf("raster %dx%d, want 5x8", img.width, img.height)
+	}
+	wantColors := map[int][3]int{1: {100, 0, 0}, 2: {0, 0, 100}}
+	if len(img.colors) != len(wantColors) {
+		t.Errorf("color registers %v, want the used colors %v", img.colors, wantColors)
+	}
+	for reg, rgb := range wantColors {
+		if img.colors[reg] != rgb {
+			t.Errorf("color register %d = %v, want %v", reg, img.colors[reg], rgb)
+		}
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 5; x++ {
+			want, ok := 1, true
+			switch {
+			case x == 0 && y == 0:
+				ok = false
+			case x >= 3 && y < 6:
+				want = 2
+			}
+			got, set := img.pixels[[2]int{x, y}]
+			if set != ok || set && got != want {
+				t.Errorf("pixel (%d, %d) = %d (set %v), want %d (set %v)", x, y, got, set, want, ok)
+			}
+		}
+	}
+	if !strings.Contains(b.String(), "!5") {
+		t.Errorf("EncodeSixel() = %q, want the run of 5 sixels compressed", b.String())
+	}
+}
+
+func TestEncodeSixelPaletteSize(t *testing.T) {
+	pal := make(color.Palette, 257)
+	for i := range pal {
+		pal[i] = color.Gray{uint8(i)}
+	}
+	var b bytes.Buffer
+	if err := EncodeSixel(&b, image.NewNRGBA(image.Rect(0, 0, 2, 2)), pal, dither.None); err != dither.ErrPaletteSize {
+		t.Errorf("EncodeSixel() error = %v, want dither.ErrPaletteSize", err)
+	}
+}
+
+// kittyChunks returns the control data and the payload of the APC sequences
+// of s
+func kittyChunks(t *testing.T, s string) (controls, payloads []string) {
+	t.Helper()
+	re := regexp.MustCompile(`\x1b_G([^;]*);([^\x1b]*)\x1b\\`)
+	if rest := re.ReplaceAllString(s, ""); rest != "" {
+		t.Fatalf("unexpected data between the APC sequences: %q", rest)
+	}
+	for _, m := range re.FindAllStringSubmatch(s, -1) {
+		controls = append(controls, m[1])
+		payloads = append(payloads, m[2])
+	}
+	return controls, payloads
+}
+
+func TestEncodeKitty(t *testing.T) {
+	// random pixels, so the PNG does not fit in one chunk
+	src := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	seed := uint32(1)
+	for i := range src.Pix {
+		seed = seed*1664525 + 1013904223
+		src.Pix[i] = byte(seed >> 24)
+	}
+
+	var b bytes.Buffer
+	if err := EncodeKitty(&b, src, &KittyOptions{Columns: 20}); err != nil {
+		t.Fatalf("EncodeKitty() error = %v", err)
+	}
+	controls, payloads := kittyChunks(t, b.String())
+	if len(controls) < 2 {
+		t.Fatalf("%d chunks, want several", len(controls))
+	}
+	if want := "a=T,q=2,f=100,c=20,m=1"; controls[0] != want {
+		t.Errorf("first control data %q, want %q", controls[0], want)
+	}
+	for i, c := range controls[1:] {
+		want := "m=1"
+		if i == len(controls)-2 {
+			want = "m=0"
+		}
+		if c != want {
+			t.Errorf("control data of chunk %d = %q, want %q", i+1, c, want)
+		}
+	}
+	for i, p := range payloads[:len(payloads)-1] {
+		if len(p) != kittyChunk {
+			t.Errorf("chunk %d has %d bytes, want %d", i, len(p), kittyChunk)
+		}
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.Join(payloads, ""))
+	if err != nil {
+		t.Fatalf("payload is not base64: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("payload is not a PNG: %v", err)
+	}
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if got := color.NRGBAModel.Convert(img.At(x, y)); got != src.NRGBAAt(x, y) {
+				t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, src.NRGBAAt(x, y))
+			}
+		}
+	}
+}
+
+func TestEncodeKittyRGBA(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, red)
+	src.SetNRGBA(1, 0, blue)
+	var b bytes.Buffer
+	if err := EncodeKitty(&b, src, &KittyOptions{RGBA: true}); err != nil {
+		t.Fatalf("EncodeKitty() error = %v", err)
+	}
+	controls, payloads := kittyChunks(t, b.String())
+	if len(controls) != 1 || controls[0] != "a=T,q=2,f=32,s=2,v=1,m=0" {
+		t.Fatalf("control data %q, want a single chunk of raw RGBA", controls)
+	}
+	want := base64.StdEncoding.EncodeToString([]byte{0xff, 0, 0, 0xff, 0, 0, 0xff, 0xff})
+	if payloads[0] != want {
+		t.Errorf("payload %q, want %q", payloads[0], want)
+	}
+}
+
+func TestRenderFallback(t *testing.T) {
+	// an empty image cannot be encoded as PNG: the blocks, i.e. nothing,
+	// are drawn instead of a broken sequence
+	p := pencil.Profile{Mode: pencil.ModeRGB}
+	empty := image.NewNRGBA(image.Rect(0, 0, 0, 0))
+	if s := Render(empty, &Options{Protocol: Kitty, Profile: &p}); s != "" {
+		t.Errorf("Render() = %q, want nothing", s)
+	}
+}
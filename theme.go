@@ -0,0 +1,132 @@
+package pencil
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Semantic style names used by the default themes
+const (
+	StyleError     = "error"
+	StyleWarning   = "warning"
+	StyleInfo      = "info"
+	StyleDebug     = "debug"
+	StyleSuccess   = "success"
+	StyleMuted     = "muted"
+	StyleHighlight = "highlight"
+)
+
+// Theme maps semantic names, such as "error" or "key", to styles.
+//
+// Names may be dotted to refine a style for a context, e.g. "json.key"; Style
+// falls back to the shorter names ("key") when the full name is not defined.
+type Theme struct {
+	Name   string
+	Styles map[string]Style
+}
+
+// NewTheme returns an empty theme named name
+func NewTheme(name string) *Theme {
+	return &Theme{Name: name, Styles: make(map[string]Style)}
+}
+
+// Clone returns a copy of the theme which can be modified independently
+func (t *Theme) Clone() *Theme {
+	c := NewTheme(t.Name)
+	for k, s := range t.Styles {
+		c.Styles[k] = s
+	}
+	return c
+}
+
+// Set sets the style of name
+func (t *Theme) Set(name string, s Style) *Theme {
+	t.Styles[name] = s
+	return t
+}
+
+// Lookup returns the style of name; dotted names fall back to their suffixes,
+// e.g. "log.level.error" -> "level.error" -> "error"
+func (t *Theme) Lookup(name string) (Style, bool) {
+	if t == nil {
+		return Style{}, false
+	}
+	for {
+		if s, ok := t.Styles[name]; ok {
+			return s, true
+		}
+		i := strings.IndexByte(name, '.')
+		if i < 0 {
+			return Style{}, false
+		}
+		name = name[i+1:]
+	}
+}
+
+// Style returns the style of name, or a zero style if it is not defined
+func (t *Theme) Style(name string) Style {
+	s, _ := t.Lookup(name)
+	return s
+}
+
+// Sprint is just like Style.Sprint with the style of name
+func (t *Theme) Sprint(name string, a ...interface{}) string {
+	return t.Style(name).Sprint(a...)
+}
+
+// Sprintf is just like Style.Sprintf with the style of name
+func (t *Theme) Sprintf(name string, format string, a ...interface{}) string {
+	return t.Style(name).Sprintf(format, a...)
+}
+
+// Names returns the sorted names of the styles defined in the theme
+func (t *Theme) Names() []string {
+	names := make([]string, 0, len(t.Styles))
+	for k := range t.Styles {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultTheme is the built-in theme using the basic colors only, so it is
+// readable on every color terminal. The features using further names, e.g.
+// PrettyJSON or package diff, add their styles to it.
+var DefaultTheme = &Theme{
+	Name: "default",
+	Styles: map[string]Style{
+		StyleError:     NewStyle(ANSIColor(1), Bold),
+		StyleWarning:   NewStyle(ANSIColor(3)),
+		StyleInfo:      NewStyle(ANSIColor(6)),
+		StyleDebug:     NewStyle(ANSIColor(8)),
+		StyleSuccess:   NewStyle(ANSIColor(2)),
+		StyleMuted:     NewStyle(ANSIColor(8)),
+		StyleHighlight: NewStyle(nil, Bold),
+	},
+}
+
+var (
+	currentTheme   = DefaultTheme
+	currentThemeMu sync.RWMutex // protects currentTheme
+)
+
+// CurrentTheme returns the theme used by the package, DefaultTheme unless
+// changed by SetTheme()
+func CurrentTheme() *Theme {
+	currentThemeMu.RLock()
+	defer currentThemeMu.RUnlock()
+
+	return currentTheme
+}
+
+// SetTheme sets the theme used by the package; nil restores DefaultTheme
+func SetTheme(t *Theme) {
+	currentThemeMu.Lock()
+	defer currentThemeMu.Unlock()
+
+	if t == nil {
+		t = DefaultTheme
+	}
+	currentTheme = t
+}
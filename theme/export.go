@@ -0,0 +1,62 @@
+package theme
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"github.com/shyang107/pencil"
+	"gopkg.in/yaml.v3"
+)
+
+// Export writes the current theme of pencil (see pencil.CurrentTheme) to w in
+// format
+func Export(w io.Writer, format Format) error {
+	return Encode(w, pencil.CurrentTheme(), format)
+}
+
+// Encode writes the theme t to w in format. The output is accepted back by
+// Decode; inherited styles are written out, so it has no "extends" key.
+func Encode(w io.Writer, t *pencil.Theme, format Format) error {
+	f := file{Name: t.Name, Styles: make(map[string]string, len(t.Styles))}
+	for name, s := range t.Styles {
+		f.Styles[name] = s.String()
+	}
+
+	switch format {
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatTOML:
+		return toml.NewEncoder(w).Encode(f)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(f)
+	default:
+		return fmt.Errorf("theme: unknown format %v", format)
+	}
+}
+
+// Save writes the theme t to the file of path in the format of its extension
+func Save(path string, t *pencil.Theme) error {
+	format, err := FormatOf(path)
+	if err != nil {
+		return err
+	}
+	fp, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Encode(fp, t, format); err != nil {
+		fp.Close()
+		return err
+	}
+	return fp.Close()
+}
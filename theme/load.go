@@ -0,0 +1,328 @@
+package theme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/shyang107/pencil"
+	"gopkg.in/yaml.v3"
+)
+
+// file is the serialised form of a theme
+type file struct {
+	Name    string            `json:"name" yaml:"name" toml:"name"`
+	Extends string            `json:"extends,omitempty" yaml:"extends,omitempty" toml:"extends,omitempty"`
+	Styles  map[string]string `json:"styles" yaml:"styles" toml:"styles"`
+}
+
+// Load reads the theme file of path. The format is chosen by the extension
+// of path (see FormatOf) and a relative "extends" path is resolved against
+// the directory of path.
+func Load(path string) (*pencil.Theme, error) {
+	return load(path, make(map[string]bool))
+}
+
+// Use loads the theme file of path and makes it the current theme of pencil
+func Use(path string) error {
+	t, err := Load(path)
+	if err != nil {
+		return err
+	}
+	pencil.SetTheme(t)
+	return nil
+}
+
+// Decode reads a theme in format from r. An "extends" key may name a
+// registered theme or the path of a theme file.
+func Decode(r io.Reader, format Format) (*pencil.Theme, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return decode(src, "", format, make(map[string]bool))
+}
+
+func load(path string, seen map[string]bool) (*pencil.Theme, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if seen[abs] {
+		return nil, &Error{File: path, Err: errors.New("cyclic \"extends\"")}
+	}
+	seen[abs] = true
+
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decode(src, path, format, seen)
+}
+
+func decode(src []byte, path string, format Format, seen map[string]bool) (*pencil.Theme, error) {
+	var f file
+	var lines map[string]int
+	var err error
+	switch format {
+	case FormatYAML:
+		f, lines, err = decodeYAML(src)
+	case FormatTOML:
+		var md toml.MetaData
+		md, err = toml.Decode(string(src), &f)
+		if err == nil {
+			if keys := md.Undecoded(); len(keys) > 0 {
+				key := keys[0].String()
+				err = &Error{Line: keyLine(string(src), keys[0][len(keys[0])-1], 0), Key: key, Err: errUnknownKey}
+			}
+		}
+	case FormatJSON:
+		f, lines, err = decodeJSON(src)
+	default:
+		err = fmt.Errorf("unknown format %v", format)
+	}
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		e.File = path
+		return nil, e
+	case err != nil:
+		return nil, &Error{File: path, Line: errorLine(src, err), Err: err}
+	}
+	if lines == nil {
+		lines = searchLines(src, f)
+	}
+
+	t := pencil.NewTheme(f.Name)
+	if f.Extends != "" {
+		base, err := resolve(f.Extends, path, seen)
+		if err != nil {
+			var e *Error
+			if errors.As(err, &e) {
+				return nil, err
+			}
+			return nil, &Error{File: path, Line: lines["extends"], Key: "extends", Err: err}
+		}
+		t = base.Clone()
+		t.Name = f.Name
+	}
+	if t.Name == "" && path != "" {
+		t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	for name, spec := range f.Styles {
+		if name == "" {
+			return nil, &Error{File: path, Line: lines["styles"], Key: "styles", Err: errors.New("empty style name")}
+		}
+		s, err := ParseStyle(spec)
+		if err != nil {
+			return nil, &Error{File: path, Line: lines["styles."+name], Key: "styles." + name, Err: err}
+		}
+		t.Styles[name] = s
+	}
+	return t, nil
+}
+
+// resolve returns the base theme named by an "extends" value of the file of
+// path: a registered theme or the path of another theme file
+func resolve(extends, path string, seen map[string]bool) (*pencil.Theme, error) {
+	if t, ok := Lookup(extends); ok {
+		return t, nil
+	}
+	if _, err := FormatOf(extends); err != nil {
+		return nil, fmt.Errorf("unknown base theme %q", extends)
+	}
+	if !filepath.IsAbs(extends) && path != "" {
+		extends = filepath.Join(filepath.Dir(path), extends)
+	}
+	return load(extends, seen)
+}
+
+// errUnknownKey is the error of a key which is not part of a theme file, in
+// any format
+var errUnknownKey = errors.New("unknown key")
+
+// decodeYAML decodes a YAML theme and records the line of each key
+func decodeYAML(src []byte) (file, map[string]int, error) {
+	var f file
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return f, nil, err
+	}
+	lines := make(map[string]int)
+	if len(doc.Content) == 0 {
+		return f, lines, nil
+	}
+	root := doc.Content[0]
+	if err := root.Decode(&f); err != nil {
+		return f, nil, err
+	}
+	if root.Kind != yaml.MappingNode {
+		return f, lines, nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, val := root.Content[i], root.Content[i+1]
+		switch key.Value {
+		case "name", "extends", "styles":
+		default:
+			return f, nil, &Error{Line: key.Line, Key: key.Value, Err: errUnknownKey}
+		}
+		lines[key.Value] = key.Line
+		if key.Value != "styles" || val.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(val.Content); j += 2 {
+			lines["styles."+val.Content[j].Value] = val.Content[j].Line
+		}
+	}
+	return f, lines, nil
+}
+
+// decodeJSON decodes a JSON theme and records the line of each key
+func decodeJSON(src []byte) (file, map[string]int, error) {
+	var f file
+	if err := json.Unmarshal(src, &f); err != nil {
+		return f, nil, err
+	}
+	lines := make(map[string]int)
+	dec := json.NewDecoder(bytes.NewReader(src))
+	// the line of the token just read
+	line := func() int {
+		return bytes.Count(src[:dec.InputOffset()], []byte("\n")) + 1
+	}
+	var raw json.RawMessage
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return f, lines, err // null
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return f, nil, err
+		}
+		key := tok.(string)
+		switch key {
+		case "name", "extends", "styles":
+		default:
+			return f, nil, &Error{Line: line(), Key: key, Err: errUnknownKey}
+		}
+		lines[key] = line()
+		if key != "styles" {
+			if err := dec.Decode(&raw); err != nil {
+				return f, nil, err
+			}
+			continue
+		}
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			if err != nil {
+				return f, nil, err
+			}
+			continue // null
+		}
+		for dec.More() {
+			name, err := dec.Token()
+			if err != nil {
+				return f, nil, err
+			}
+			lines["styles."+name.(string)] = line()
+			if err := dec.Decode(&raw); err != nil {
+				return f, nil, err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return f, nil, err
+		}
+	}
+	return f, lines, nil
+}
+
+// searchLines finds the line of each key of the TOML f by searching src for
+// the key followed by '='
+func searchLines(src []byte, f file) map[string]int {
+	lines := make(map[string]int)
+	text := string(src)
+	lines["extends"] = keyLine(text, "extends", 0)
+	start := keyLine(text, "styles", 0)
+	if start == 0 {
+		start = strings.Index(text, "[styles]")
+		if start >= 0 {
+			start = strings.Count(text[:start], "\n") + 1
+		} else {
+			start = 0
+		}
+	}
+	lines["styles"] = start
+	for name := range f.Styles {
+		lines["styles."+name] = keyLine(text, name, start)
+	}
+	return lines
+}
+
+// keyLine returns the line (1-based) of the first occurrence of key, quoted
+// or bare, followed by ':' or '=' at or after the line from; 0 if not found
+func keyLine(text, key string, from int) int {
+	pattern := `(?m)^.*?(?:"` + regexp.QuoteMeta(key) + `"|'` + regexp.QuoteMeta(key) +
+		`'|\b` + regexp.QuoteMeta(key) + `\b)\s*[:=]`
+	re := regexp.MustCompile(pattern)
+	offset := 0
+	if from > 1 {
+		offset = lineOffset(text, from)
+	}
+	loc := re.FindStringIndex(text[offset:])
+	if loc == nil {
+		return 0
+	}
+	return strings.Count(text[:offset+loc[1]], "\n") + 1
+}
+
+func lineOffset(text string, line int) int {
+	offset := 0
+	for l := 1; l < line; l++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	return offset
+}
+
+var yamlLineRe = regexp.MustCompile(`line (\d+)`)
+
+// errorLine returns the line of a syntax error of a decoder; 0 if unknown
+func errorLine(src []byte, err error) int {
+	var jsyn *json.SyntaxError
+	var jtyp *json.UnmarshalTypeError
+	var terr toml.ParseError
+	switch {
+	case errors.As(err, &jsyn):
+		return bytes.Count(src[:clamp(jsyn.Offset, len(src))], []byte("\n")) + 1
+	case errors.As(err, &jtyp):
+		return bytes.Count(src[:clamp(jtyp.Offset, len(src))], []byte("\n")) + 1
+	case errors.As(err, &terr):
+		return terr.Position.Line
+	}
+	if m := yamlLineRe.FindStringSubmatch(err.Error()); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return 0
+}
+
+func clamp(offset int64, n int) int {
+	if offset > int64(n) {
+		return n
+	}
+	return int(offset)
+}
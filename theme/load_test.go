@@ -0,0 +1,74 @@
+package theme
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shyang107/pencil"
+)
+
+func TestDecodeUnknownKey(t *testing.T) {
+	tests := []struct {
+		format Format
+		src    string
+		key    string
+		line   int
+	}{
+		{FormatYAML, "name: x\nstyle:\n  error: red\n", "style", 2},
+		{FormatTOML, "name = \"x\"\ncolour = \"red\"\n", "colour", 2},
+		{FormatJSON, "{\"name\": \"x\",\n  \"stles\": {}}", "stles", 2},
+	}
+	for _, tt := range tests {
+		_, err := Decode(strings.NewReader(tt.src), tt.format)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%v: Decode() error = %v, want an *Error", tt.format, err)
+			continue
+		}
+		if e.Key != tt.key || e.Line != tt.line || !errors.Is(err, errUnknownKey) {
+			t.Errorf("%v: Decode() error = %v, want key %q at line %d", tt.format, err, tt.key, tt.line)
+		}
+	}
+}
+
+func TestDecodeStyleLine(t *testing.T) {
+	tests := []struct {
+		format Format
+		src    string
+		line   int
+	}{
+		{FormatYAML, "name: x\nstyles:\n  a: red\n  b: no-such-color\n", 4},
+		{FormatTOML, "name = \"x\"\n[styles]\na = \"red\"\nb = \"no-such-color\"\n", 4},
+		{FormatJSON, "{\"name\": \"x\",\n  \"styles\": {\"a\": \"red\",\n    \"b\": \"no-such-color\"}}", 3},
+	}
+	for _, tt := range tests {
+		_, err := Decode(strings.NewReader(tt.src), tt.format)
+		var e *Error
+		if !errors.As(err, &e) || e.Key != "styles.b" || e.Line != tt.line {
+			t.Errorf("%v: Decode() error = %v, want the style b at line %d", tt.format, err, tt.line)
+		}
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	th := pencil.NewTheme("round").
+		Set("error", pencil.NewStyle(pencil.ANSIColor(1), pencil.Bold, pencil.Attribute(53))).
+		Set("key", pencil.NewStyle(pencil.RGBColor(0xff, 0x88, 0), pencil.Italic).On(pencil.IndexColor(236)))
+	for _, format := range []Format{FormatYAML, FormatTOML, FormatJSON} {
+		var b bytes.Buffer
+		if err := Encode(&b, th, format); err != nil {
+			t.Fatalf("%v: Encode() error = %v", format, err)
+		}
+		got, err := Decode(&b, format)
+		if err != nil {
+			t.Fatalf("%v: Decode() error = %v", format, err)
+		}
+		for name, want := range th.Styles {
+			if s := got.Style(name); s.String() != want.String() {
+				t.Errorf("%v: style %q = %q, want %q", format, name, s, want)
+			}
+		}
+	}
+}
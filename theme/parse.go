@@ -0,0 +1,128 @@
+package theme
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/rgb16b"
+)
+
+// ParseColor parses a color in any of the following syntaxes:
+//
+//	"#ff8800", "#f80"   : 24-bit hex color
+//	"202"               : 256-colors index (0-255)
+//	"red", "hiblue"     : basic ANSI color (as in ansi8; "bright" = "hi")
+//	"orange", "navy"    : SVG 1.1 color name of rgb16b.Map
+//
+// The prefixes "ansi:" and "svg:" select one of the name tables explicitly,
+// e.g. "svg:red" is the 24-bit SVG red instead of the terminal's red.
+func ParseColor(s string) (*pencil.ColorSpec, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case name == "":
+		return nil, fmt.Errorf("empty color")
+	case strings.HasPrefix(name, "#"):
+		return parseHex(name[1:])
+	case strings.HasPrefix(name, "ansi:"):
+		if code, ok := pencil.ANSIColorByName(name[len("ansi:"):]); ok {
+			return pencil.ANSIColor(code), nil
+		}
+		return nil, fmt.Errorf("unknown ANSI color %q", s)
+	case strings.HasPrefix(name, "svg:"):
+		if cl, ok := svgColor(name[len("svg:"):]); ok {
+			return cl, nil
+		}
+		return nil, fmt.Errorf("unknown SVG color %q", s)
+	}
+
+	if n, err := strconv.Atoi(name); err == nil {
+		if n < 0 || n > 255 {
+			return nil, fmt.Errorf("color index %d out of range [0, 255]", n)
+		}
+		return pencil.IndexColor(pencil.ColorCode(n)), nil
+	}
+	if code, ok := pencil.ANSIColorByName(name); ok {
+		return pencil.ANSIColor(code), nil
+	}
+	if cl, ok := svgColor(name); ok {
+		return cl, nil
+	}
+	return nil, fmt.Errorf("unknown color %q", s)
+}
+
+func parseHex(hex string) (*pencil.ColorSpec, error) {
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("invalid hex color %q", "#"+hex)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex color %q", "#"+hex)
+	}
+	return pencil.RGBColor(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+}
+
+func svgColor(name string) (*pencil.ColorSpec, bool) {
+	name = strings.NewReplacer(" ", "", "-", "", "_", "").Replace(name)
+	cl, ok := rgb16b.Map[name]
+	if !ok {
+		return nil, false
+	}
+	rgba := color.RGBAModel.Convert(cl).(color.RGBA)
+	return pencil.RGBColor(rgba.R, rgba.G, rgba.B), true
+}
+
+// ParseStyle parses the textual form of a style: attribute names, an
+// optional foreground color and an optional background color after "on",
+// separated by spaces or commas, e.g.
+//
+//	"bold red"
+//	"italic #ff8800 on 236"
+//	"underline on navy"
+//
+// "none" (or an empty string) is a style without colors and attributes.
+func ParseStyle(spec string) (pencil.Style, error) {
+	var s pencil.Style
+	background := false
+	words := strings.FieldsFunc(spec, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ','
+	})
+	for _, word := range words {
+		lower := strings.ToLower(word)
+		switch lower {
+		case "none", "default", "plain":
+			continue
+		case "on":
+			if background {
+				return pencil.Style{}, fmt.Errorf("style %q: duplicate \"on\"", spec)
+			}
+			background = true
+			continue
+		}
+		if attr, ok := pencil.AttributeByName(lower); ok && !background {
+			s.Attrs = append(s.Attrs, attr)
+			continue
+		}
+		cl, err := ParseColor(word)
+		if err != nil {
+			return pencil.Style{}, fmt.Errorf("style %q: %v", spec, err)
+		}
+		switch {
+		case background && s.Bg == nil:
+			s.Bg = cl
+		case !background && s.Fg == nil:
+			s.Fg = cl
+		default:
+			return pencil.Style{}, fmt.Errorf("style %q: too many colors", spec)
+		}
+	}
+	if background && s.Bg == nil {
+		return pencil.Style{}, fmt.Errorf("style %q: missing background color after \"on\"", spec)
+	}
+	return s, nil
+}
@@ -0,0 +1,130 @@
+// Package theme loads and exports pencil themes from YAML, TOML and JSON files.
+//
+// A theme file names the theme, optionally extends a base theme and lists the
+// styles by semantic name, e.g. in YAML:
+//
+//	name: ops
+//	extends: default      # a registered theme or the path of another file
+//	styles:
+//	  error: bold red
+//	  warning: "#ff8800"
+//	  json.key: italic 75 on navy
+//
+// Styles use the syntax of ParseStyle; colours use the syntax of ParseColor.
+package theme
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shyang107/pencil"
+)
+
+// Format is the serialisation format of a theme file
+type Format int
+
+// Theme file formats
+const (
+	FormatYAML Format = iota
+	FormatTOML
+	FormatJSON
+)
+
+// String returns the name of the format
+func (f Format) String() string {
+	switch f {
+	case FormatYAML:
+		return "yaml"
+	case FormatTOML:
+		return "toml"
+	case FormatJSON:
+		return "json"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// FormatOf returns the format of a theme file from the extension of its path
+func FormatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	case ".json":
+		return FormatJSON, nil
+	default:
+		return 0, fmt.Errorf("theme: unknown format of %q", path)
+	}
+}
+
+// Error is an error of a theme file with its position
+type Error struct {
+	File string // path of the file; empty if not read from a file
+	Line int    // line number (1-based); 0 if unknown
+	Key  string // offending key, e.g. "styles.error"; empty if unknown
+	Err  error
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File)
+	} else {
+		b.WriteString("theme")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+	}
+	b.WriteString(": ")
+	if e.Key != "" {
+		b.WriteString(e.Key)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+var (
+	registry   = map[string]*pencil.Theme{"default": pencil.DefaultTheme}
+	registryMu sync.RWMutex // protects registry
+)
+
+// Register makes the theme t available under name to the "extends" key of
+// theme files
+func Register(name string, t *pencil.Theme) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry[name] = t
+}
+
+// Lookup returns the theme registered under name
+func Lookup(name string) (*pencil.Theme, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	t, ok := registry[name]
+	return t, ok
+}
+
+// Registered returns the sorted names of the registered themes
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,88 @@
+package pencil
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// Profiler is implemented by writers carrying their own color profile, such
+// as Writer. The Fprint functions of ansi8, ansi256 and rgb16b consult it
+// instead of the global NoColor.
+type Profiler interface {
+	ColorProfile() Profile
+}
+
+// Writer is an io.Writer carrying the color profile of its output, so the
+// decision to colorize is made per writer instead of by the global NoColor
+// which is derived from os.Stdout:
+//
+//	stderr := pencil.NewWriter(os.Stderr)
+//	ansi8.New(ansi8.FgRed).Fprintln(stderr, "failed")
+type Writer struct {
+	io.Writer
+	Profile Profile
+}
+
+// NewWriter returns a Writer on w with the profile detected from w: the
+// profile of w itself if it is a Profiler, DetectProfile() of w if it is an
+// *os.File, otherwise colors are disabled unless forced by the environment
+// (see DetectProfile).
+func NewWriter(w io.Writer) *Writer {
+	if p, ok := w.(Profiler); ok {
+		return &Writer{Writer: w, Profile: p.ColorProfile()}
+	}
+	f, _ := w.(*os.File)
+	return &Writer{Writer: w, Profile: DetectProfile(f)}
+}
+
+// NewWriterProfile returns a Writer on w with the explicit profile p
+func NewWriterProfile(w io.Writer, p Profile) *Writer {
+	return &Writer{Writer: w, Profile: p}
+}
+
+// NewLockedWriter is NewWriter with the writes to w serialized by a mutex,
+// for outputs shared by goroutines whose writes may not be atomic, e.g. a
+// bytes.Buffer. Each print of ansi8, ansi256 and rgb16b is a single write, so
+// the colored texts are not interleaved.
+//
+// As with NewWriter, colors are disabled on a w which is neither a Profiler
+// nor a terminal, such as a bytes.Buffer; use NewLockedWriterProfile to
+// color it.
+func NewLockedWriter(w io.Writer) *Writer {
+	l := NewWriter(w)
+	l.Writer = &lockedWriter{w: w}
+	return l
+}
+
+// NewLockedWriterProfile is NewLockedWriter with the explicit profile p
+func NewLockedWriterProfile(w io.Writer, p Profile) *Writer {
+	return &Writer{Writer: &lockedWriter{w: w}, Profile: p}
+}
+
+// lockedWriter is an io.Writer serializing the writes to w
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// ColorProfile implements Profiler
+func (w *Writer) ColorProfile() Profile {
+	return w.Profile
+}
+
+// ProfileOf returns the color profile carried by w. If w is not a Profiler,
+// ok is false and the profile is the one of the package output, i.e. the
+// global NoColor with the mode and hyperlinks of ColorProfile.
+func ProfileOf(w io.Writer) (p Profile, ok bool) {
+	if pr, ok := w.(Profiler); ok {
+		return pr.ColorProfile(), true
+	}
+	return defaultProfile(), false
+}
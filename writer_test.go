@@ -0,0 +1,132 @@
+package pencil_test
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shyang107/pencil"
+	"github.com/shyang107/pencil/ansi256"
+	"github.com/shyang107/pencil/ansi8"
+	"github.com/shyang107/pencil/rgb16b"
+)
+
+// printers print a numbered line in a color of each package
+var printers = []func(w io.Writer, i, j int){
+	func(w io.Writer, i, j int) { ansi8.New(ansi8.FgRed, pencil.Bold).Fprintln(w, "ansi8", i, j) },
+	func(w io.Writer, i, j int) { ansi256.New(208, pencil.Foreground).Fprintf(w, "ansi256 %d %d\n", i, j) },
+	func(w io.Writer, i, j int) {
+		rgb16b.New(color.RGBA{0x12, 0x34, 0x56, 0xff}).Fprintln(w, "rgb16b", i, j)
+	},
+	func(w io.Writer, i, j int) {
+		fmt.Fprint(w, pencil.NewStyle(pencil.ANSIColor(2)).Sprintln("style", i, j))
+	},
+}
+
+func TestLockedWriterConcurrent(t *testing.T) {
+	const goroutines, lines = 16, 50
+	p := pencil.Profile{Mode: pencil.ModeRGB}
+
+	var b bytes.Buffer
+	w := pencil.NewLockedWriterProfile(&b, p)
+	if w.ColorProfile() != p {
+		t.Fatalf("ColorProfile() = %+v, want %+v", w.ColorProfile(), p)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < lines; j++ {
+				printers[j%len(printers)](w, i, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// the output is the prints made alone, each written whole
+	want := map[string]int{}
+	for i := 0; i < goroutines; i++ {
+		for j := 0; j < lines; j++ {
+			var print bytes.Buffer
+			printers[j%len(printers)](pencil.NewWriterProfile(&print, p), i, j)
+			want[print.String()]++
+		}
+	}
+	out := b.String()
+	for out != "" {
+		n := 0
+		for print := range want {
+			if strings.HasPrefix(out, print) {
+				n = len(print)
+				if want[print]--; want[print] == 0 {
+					delete(want, print)
+				}
+				break
+			}
+		}
+		if n == 0 {
+			if len(out) > 40 {
+				out = out[:40]
+			}
+			t.Fatalf("interleaved or uncolored output at %q", out)
+		}
+		out = out[n:]
+	}
+	if len(want) > 0 {
+		t.Errorf("%d prints missing", len(want))
+	}
+}
+
+func TestLockedWriterProfile(t *testing.T) {
+	// a bytes.Buffer is no terminal: NewLockedWriter disables the colors
+	// unless forced by the environment
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "0")
+	var b bytes.Buffer
+	if p := pencil.NewLockedWriter(&b).ColorProfile(); !p.NoColor {
+		t.Errorf("NewLockedWriter(&bytes.Buffer{}).ColorProfile() = %+v, want NoColor", p)
+	}
+	w := pencil.NewLockedWriterProfile(&b, pencil.Profile{Mode: pencil.ModeANSI8})
+	ansi8.New(ansi8.FgRed).Fprint(w, "x")
+	if !strings.HasPrefix(b.String(), "\x1b[31mx") {
+		t.Errorf("written %q, want the red x", b.String())
+	}
+}
+
+func TestFprintCount(t *testing.T) {
+	// the count of Fprint* is the bytes written, the escapes included
+	prints := map[string]func(w io.Writer) (int, error){
+		"ansi8.Fprint":    func(w io.Writer) (int, error) { return ansi8.New(ansi8.FgRed).Fprint(w, "text") },
+		"ansi8.Fprintln":  func(w io.Writer) (int, error) { return ansi8.New(ansi8.FgRed).Fprintln(w, "text") },
+		"ansi256.Fprintf": func(w io.Writer) (int, error) { return ansi256.New(208).Fprintf(w, "%s", "text") },
+		"ansi256.FBFprint": func(w io.Writer) (int, error) {
+			return ansi256.FBFprint(w, 208, 17, "text")
+		},
+		"rgb16b.Fprint": func(w io.Writer) (int, error) {
+			return rgb16b.New(color.RGBA{0x12, 0x34, 0x56, 0xff}).Fprint(w, "text")
+		},
+		"rgb16b.FBFprintln": func(w io.Writer) (int, error) {
+			return rgb16b.FBFprintln(w, color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0, 0xff, 0xff}, "text")
+		},
+	}
+	for name, print := range prints {
+		for _, p := range []pencil.Profile{{Mode: pencil.ModeANSI8}, {NoColor: true}} {
+			var b bytes.Buffer
+			n, err := print(pencil.NewWriterProfile(&b, p))
+			if err != nil || n != b.Len() {
+				t.Errorf("%s with %+v = %d, %v, wrote %d bytes", name, p, n, err, b.Len())
+			}
+			if text := strings.TrimSuffix(pencil.Strip(b.String()), "\n"); text != "text" {
+				t.Errorf("%s with %+v wrote %q", name, p, b.String())
+			}
+			if colored := strings.Contains(b.String(), "\x1b["); colored == p.NoColor {
+				t.Errorf("%s with %+v wrote %q", name, p, b.String())
+			}
+		}
+	}
+}